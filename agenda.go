@@ -29,14 +29,18 @@ package agenda
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
 	"encoding/hex"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Strum355/go-difflib/difflib"
 )
@@ -51,6 +55,11 @@ type Test func(path string, data []byte) ([]byte, error)
 // raw file byte data into a string suitable for diff-ing
 type StringSerializerFunc func(data []byte) (string, error)
 
+// ComparatorFunc defines the callback function that is used to compare
+// the reference data with the generated output. It returns an empty string
+// if both are considered equal, or a human-readable report on the differences
+type ComparatorFunc func(reference, output []byte) (string, error)
+
 // optionSet is an internal structure that contains all the
 // computed options before the tests are run with Run().
 // The structure is not created or modified directly;
@@ -60,6 +69,106 @@ type optionSet struct {
 	resultSuffix  string
 	initMode      bool
 	serializeFunc StringSerializerFunc
+	compareFunc   ComparatorFunc
+	fs            WritableFS
+	sample        float64
+	sampleSeed    int64
+	recursive     bool
+	historyPath   string
+	prioritize    bool
+	confine       bool
+	roots         []string
+	resultDir     string
+	sanitize      bool
+	reportPath    string
+	reportKey     []byte
+
+	errorSnapshots bool
+	shadow         bool
+	shadowSink     ShadowSink
+	retries        int
+	retryBackoff   time.Duration
+	verifyKey      ed25519.PublicKey
+	manifest       manifest
+	caseEnv        bool
+	variants       []Variant
+	caseRetries    int
+	timeout        time.Duration
+	fileMode       os.FileMode
+	dirMode        os.FileMode
+	normalizers    []normalizer
+	hashOnly       bool
+	hashDump       bool
+	mirrorDirs     bool
+	slowest        int
+
+	progressEvery    int
+	progressInterval time.Duration
+
+	logLevel logLevel
+	logger   LogPrinter
+
+	observers []Observer
+
+	checksums      bool
+	checksumStrict bool
+	checksumList   manifest
+
+	outputTransforms []func(path string, output []byte) ([]byte, error)
+
+	tags []string
+
+	inputTransforms []func(path string, data []byte) ([]byte, error)
+
+	stampsPath string
+	maxAge     time.Duration
+
+	migrateFrom []option
+
+	pending bool
+
+	diffTool string
+
+	patchPath string
+	patch     *patchSet
+
+	initFlag  bool // init mode is enabled by the command-line argument
+	initGuard bool
+
+	strict   bool
+	fixtures []string
+
+	expectCases bool
+	minCases    int
+	maxCases    int
+
+	masks [][2][]byte // pairs of masked values and their placeholders
+
+	schemaPath string
+
+	inputValidators []func(path string, data []byte) error
+
+	determinismRuns int
+
+	shardIndex int
+	shardTotal int
+
+	cachePath    string
+	cacheVersion string
+
+	warnings *int // number of mismatches in warn-only mode, or nil
+
+	annotationPrefix string
+
+	githubOutput io.Writer
+
+	allureDir string
+
+	tracer   Tracer
+	traceCtx context.Context
+
+	rerunFailed bool
+	failedFirst bool
 }
 
 func serializeUTF8Bytes(data []byte) (string, error) {
@@ -114,6 +223,7 @@ func ResultSuffix(suffix string) option {
 func InitMode(enabled bool) option {
 	return func(o *optionSet) {
 		o.initMode = enabled
+		o.initFlag = false
 	}
 }
 
@@ -141,13 +251,24 @@ func Serializer(f StringSerializerFunc) option {
 }
 
 // BinarySerializer is a shortcut option that sets the binary data
-// serializer function to render diffs for binary files
+// serializer function to render diffs for binary files. By default,
+// the data is rendered like `hexdump -C` does; the layout can be tuned
+// with HexdumpOption values, e.g. for protocols with fixed record sizes.
 //
 // Example:
 //
 // agenda.Run(t, "./testdata/mytest", testFunc, agenda.BinarySerializer())
-func BinarySerializer() option {
-	return Serializer(serializeBinaryData)
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.BinarySerializer(
+//     agenda.HexBytesPerRow(12), agenda.HexGroup(4), agenda.HexNoASCII()))
+func BinarySerializer(options ...HexdumpOption) option {
+	if len(options) == 0 {
+		return Serializer(serializeBinaryData)
+	}
+
+	f := newHexdumpFormat(options)
+	return Serializer(func(data []byte) (string, error) {
+		return f.dump(data), nil
+	})
 }
 
 // UTF8Serializer is a shortcut option that sets the UTF8 string
@@ -163,6 +284,38 @@ func UTF8Serializer() option {
 	return Serializer(serializeUTF8Bytes)
 }
 
+// Recursive enables scanning of the subdirectories of the test directory.
+// Each subdirectory becomes a nested subtest, so you can run e.g.
+// `go test -run TestX/api/v2` to target the whole subtree.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Recursive())
+func Recursive() option {
+	return func(o *optionSet) {
+		o.recursive = true
+	}
+}
+
+// Comparator allows you to specify the callback function
+// that compares the reference data with the generated output
+// and renders the report on the differences. When set, it replaces
+// the default byte-by-byte comparison and the text diff
+// rendered with the help of the Serializer.
+//
+// Example:
+//
+// function compareFiles(reference, output []byte) (string, error) {
+//     // compare the data and describe the differences
+//     // ...
+// }
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Comparator(compareFiles))
+func Comparator(f ComparatorFunc) option {
+	return func(o *optionSet) {
+		o.compareFunc = f
+	}
+}
+
 // Run executes an agenda test function (`test`) against all input data files
 // in the specified directory `dir`. Directory can be relative to the directory
 // you run the tests from. One or more `option`s allow you to control the behavior
@@ -200,31 +353,364 @@ func UTF8Serializer() option {
 // Next time the test is run in regular mode (`go test`), Agenda will
 // read the 01.json.result file and compare it with the current test output.
 func Run(t *testing.T, dir string, test Test, options ...option) {
+	RunDirs(t, []string{dir}, test, options...)
+}
+
+// RunDirs is similar to Run, but gathers the input data files
+// from several directories at once and runs the test against all of them
+// with the same set of options. Each directory becomes a separate subtest.
+//
+// Example:
+//
+// agenda.RunDirs(t, []string{"testdata/a", "testdata/b"}, testFunc)
+func RunDirs(t *testing.T, dirs []string, test Test, options ...option) {
 	if test == nil {
 		panic("test function is nil")
 	}
 
+	runDirs(t, dirs, test, newOptionSet(options))
+}
+
+// runDirs is an internal function that gathers the cases from the directories,
+// runs the test against them and returns the processed cases
+func runDirs(t *testing.T, dirs []string, test Test, opt *optionSet) []*testCase {
+	opt.roots = append(opt.roots, dirs...)
+	if opt.resultDir != "" {
+		opt.roots = append(opt.roots, opt.resultDir)
+		opt.mirrorDirs = len(dirs) > 1
+	}
+
+	var cases []*testCase
+	for _, dir := range dirs {
+		dirCases := findCases(t, dir, opt)
+		if opt.strict {
+			checkStrict(t, dir, dirCases, opt)
+		}
+		loadManifests(t, dir, opt)
+		if len(dirs) > 1 {
+			for _, c := range dirCases {
+				c.name = filepath.ToSlash(dir) + "/" + c.name
+			}
+		}
+		cases = append(cases, dirCases...)
+	}
+
+	if opt.expectCases {
+		if err := checkCaseCount(len(cases), opt); err != nil {
+			t.Fatalf("Unexpected number of cases: %v", err)
+		}
+	}
+	if len(opt.inputValidators) > 0 {
+		validateInputs(t, cases, opt)
+	}
+
+	cases = selectCases(t, cases, opt)
+
+	runCases(t, cases, test, opt)
+
+	if opt.checksums && opt.initMode {
+		for _, dir := range dirs {
+			if err := updateManifest(dir, opt); err != nil {
+				t.Errorf("Can't update the manifest: %v", err)
+			}
+		}
+	}
+	return cases
+}
+
+// loadManifests is an internal function that reads the signed manifest
+// and the checksums of the directory, if they are enabled
+func loadManifests(t *testing.T, dir string, opt *optionSet) {
+	if opt.verifyKey != nil && !opt.initMode {
+		m, err := verifyManifest(dir, opt.verifyKey)
+		if err != nil {
+			t.Fatalf("Can't verify the manifest: %v", err)
+		}
+		if opt.manifest == nil {
+			opt.manifest = make(manifest)
+		}
+		for path, sum := range m {
+			opt.manifest[path] = sum
+		}
+	}
+	if opt.checksums && !opt.initMode {
+		if err := loadChecksums(dir, opt); err != nil {
+			t.Fatalf("Can't read the manifest: %v", err)
+		}
+	}
+}
+
+// rejectRunOptions is an internal function that fails the test if
+// the options which track the outcome of the cases (and thus require
+// them to be run with runCases()) are used with another runner
+func rejectRunOptions(t *testing.T, runner string, opt *optionSet) {
+	if names := caseTrackingOptions(opt); len(names) > 0 {
+		t.Fatalf("%s doesn't support the %s option(s)", runner, strings.Join(names, ", "))
+	}
+}
+
+// caseTrackingOptions is an internal function that returns the names
+// of the used options which track the outcome of the cases
+func caseTrackingOptions(opt *optionSet) []string {
+	var names []string
+	check := func(used bool, name string) {
+		if used {
+			names = append(names, name)
+		}
+	}
+	check(len(opt.observers) > 0, "Observe() or TAP()")
+	check(opt.historyPath != "", "History()")
+	check(opt.cachePath != "", "Cache()")
+	check(opt.caseRetries > 0, "RetryFailedCases()")
+	check(opt.warnings != nil, "WarnOnly()")
+	check(opt.progressEvery > 0 || opt.progressInterval > 0, "Progress()")
+	check(opt.tracer != nil, "Trace()")
+	check(opt.shadow, "Shadow()")
+	check(opt.slowest > 0, "Timing()")
+	check(opt.patchPath != "", "Patch()")
+	check(opt.stampsPath != "", "Staleness()")
+	check(opt.allureDir != "", "AllureResults()")
+	return names
+}
+
+// runCases is an internal function that runs the test against
+// the selected cases and records their outcome
+func runCases(t *testing.T, cases []*testCase, test Test, opt *optionSet) {
+	start := time.Now()
+	for _, o := range opt.observers {
+		o.OnRunStart(len(cases))
+	}
+
+	var runCtx context.Context
+	var runSpan Span
+	if opt.tracer != nil {
+		runCtx, runSpan = opt.tracer.Start(opt.traceCtx, t.Name())
+	}
+
+	var cacheKeys map[*testCase]string
+	var cached map[*testCase]bool
+	if opt.cachePath != "" && !opt.initMode {
+		cacheKeys, cached = cachedKeys(t, cases, opt)
+	}
+
+	p := newProgress(t, len(cases), opt)
+	runNested(t, cases, 0, func(t *testing.T, c *testCase) {
+		if runSpan != nil {
+			_, span := opt.tracer.Start(runCtx, c.name)
+			defer endCaseSpan(span, c, opt)
+		}
+		if c.skip != "" {
+			t.Skip(c.skip)
+		}
+		if cached[c] {
+			// cached cases passed the last time they were run
+			for _, o := range opt.observers {
+				o.OnCaseStart(c.path)
+				o.OnCasePass(c.path)
+			}
+			if p != nil {
+				p.step()
+			}
+			t.Skip("unchanged since the last successful run (see Cache())")
+		}
+		opt := caseOptions(c, opt)
+		ct := &caseT{T: t, c: c, opt: opt}
+		var tracked, warnings int
+		if tfs, ok := opt.fs.(*trackingFS); ok {
+			tracked = len(tfs.written)
+		}
+		if opt.warnings != nil {
+			warnings = *opt.warnings
+		}
+		defer func() {
+			c.ran, c.failed = true, ct.failed()
+			c.warned = opt.warnings != nil && *opt.warnings > warnings
+			c.failures = ct.failures
+			if tfs, ok := opt.fs.(*trackingFS); ok {
+				c.written = tfs.written[tracked:]
+			}
+			notifyCase(c, ct.failures, opt)
+			if p != nil {
+				p.step()
+			}
+		}()
+		for _, o := range opt.observers {
+			o.OnCaseStart(c.path)
+		}
+		retryCase(ct, c, test, opt)
+	})
+
+	if opt.shadow {
+		reportShadow(t, cases, opt)
+	}
+
+	if opt.warnings != nil {
+		logf(t, opt, logAlways, "Warn-only mode: %d mismatches found", *opt.warnings)
+	}
+
+	if opt.historyPath != "" {
+		updateHistory(t, cases, opt)
+	}
+
+	if cacheKeys != nil {
+		updateCache(t, cases, cacheKeys, opt)
+	}
+
+	if opt.slowest > 0 {
+		reportTiming(t, cases, opt)
+	}
+
+	if opt.patch != nil && !opt.initMode {
+		writePatch(t, opt)
+	}
+
+	if opt.stampsPath != "" {
+		if opt.initMode {
+			updateStamps(t, cases, opt)
+		} else {
+			reportStaleness(t, cases, opt)
+		}
+	}
+
+	if opt.allureDir != "" {
+		writeAllure(t, cases, opt)
+	}
+
+	if len(opt.observers) > 0 {
+		summary := summarize(cases, start, opt)
+		for _, o := range opt.observers {
+			o.OnRunEnd(summary)
+		}
+	}
+
+	if runSpan != nil {
+		endRunSpan(runSpan, summarize(cases, start, opt))
+	}
+}
+
+// testCase is an internal structure that describes
+// a single input data file and its result file
+type testCase struct {
+	name       string // slash-separated subtest name
+	path       string
+	resultPath string
+	data       []byte // input data of cases that don't have a file
+	provided   bool
+	env        map[string]string
+	ran        bool
+	failed     bool
+	warned     bool          // mismatches were reported as warnings (see WarnOnly())
+	duration   time.Duration // execution time of the test function
+	started    time.Time
+	size       int // size of the input data in bytes
+	failures   []string
+	written    []string // files written in initialization mode
+	tags       []string
+	skip       string   // reason to skip the case
+	options    []option // per-case option overrides
+	input      []byte   // input and transformed output data kept for AllureResults()
+	output     []byte
+}
+
+// readInput is an internal function that returns the input data of the case
+// with the input transformations applied
+func (c *testCase) readInput(opt *optionSet) ([]byte, error) {
+	data, err := c.readRawInput(opt)
+	if err != nil {
+		return nil, err
+	}
+	return transformInput(c.path, data, opt)
+}
+
+// readRawInput is an internal function that returns
+// the input data of the case as it is stored
+func (c *testCase) readRawInput(opt *optionSet) ([]byte, error) {
+	if c.provided {
+		return c.data, nil
+	}
+	return readFile(c.path, opt)
+}
+
+// runNested is an internal function that runs each case as a subtest,
+// creating a nested subtest for each slash-separated element
+// of the case name. Groups are run in the order of their first case.
+func runNested(t *testing.T, cases []*testCase, level int, f func(t *testing.T, c *testCase)) {
+	var order []string
+	groups := make(map[string][]*testCase)
+
+	for _, c := range cases {
+		parts := strings.Split(c.name, "/")
+		if level == len(parts)-1 {
+			order = append(order, "")
+			groups[""] = append(groups[""], c)
+			continue
+		}
+		if _, ok := groups[parts[level]]; !ok {
+			order = append(order, parts[level])
+		}
+		groups[parts[level]] = append(groups[parts[level]], c)
+	}
+
+	leaf := 0
+	for _, name := range order {
+		if name == "" {
+			c := groups[""][leaf]
+			leaf++
+			t.Run(path.Base(c.name), func(t *testing.T) {
+				f(t, c)
+			})
+			continue
+		}
+
+		group := groups[name]
+		t.Run(name, func(t *testing.T) {
+			runNested(t, group, level+1, f)
+		})
+	}
+}
+
+// newOptionSet is an internal function that computes
+// the options, starting from the default values
+func newOptionSet(options []option) *optionSet {
 	opt := &optionSet{
 		fileSuffix:    ".json",
 		resultSuffix:  ".result",
 		initMode:      flag.Arg(0) == "init",
+		initFlag:      flag.Arg(0) == "init",
+		initGuard:     os.Getenv("CI") != "",
 		serializeFunc: serializeUTF8Bytes,
+		fs:            osFS{},
+		fileMode:      0644,
+		dirMode:       0755,
 	}
 
 	for _, f := range options {
 		f(opt)
 	}
 
+	return opt
+}
+
+// findCases is an internal function that gathers all input data files
+// in the specified directory
+func findCases(t *testing.T, dir string, opt *optionSet) []*testCase {
+	guardInit(t, opt)
+	if opt.recursive {
+		registerTree(dir)
+	} else {
+		registerDir(dir)
+	}
+
 	if opt.initMode {
-		t.Logf("Initializing snapshots for %s directory", dir)
+		logf(t, opt, logInfo, "Initializing snapshots for %s directory", dir)
 	} else {
-		t.Logf("Running snapshot-based tests for %s directory", dir)
+		logf(t, opt, logInfo, "Running snapshot-based tests for %s directory", dir)
 	}
 
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		if opt.initMode {
-			t.Logf("Creating directory '%s'", dir)
-			err := os.MkdirAll(dir, 0755)
+			logf(t, opt, logInfo, "Creating directory '%s'", dir)
+			err := os.MkdirAll(dir, opt.dirMode)
 			if err != nil {
 				t.Fatalf("Can't create the snapshot directory: %v", err)
 			}
@@ -233,112 +719,276 @@ func Run(t *testing.T, dir string, test Test, options ...option) {
 		}
 	}
 
-	// Process the files in the directory
-
-	files, err := ioutil.ReadDir(dir)
+	cases, err := listCases(dir, opt)
 	if err != nil {
 		t.Fatalf("Can't read the directory contents: %v", err)
 	}
 
-	found := false
-	for _, f := range files {
-		if !f.IsDir() && strings.HasSuffix(f.Name(), opt.fileSuffix) {
-			found = true
-			processFile(t, filepath.Join(dir, f.Name()), test, opt)
-		}
+	if len(cases) == 0 && !opt.initMode {
+		t.Fatalf("No files ending with '%s' found in '%s' directory", opt.fileSuffix, dir)
 	}
 
-	if !found && !opt.initMode {
-		t.Fatalf("No files ending with '%s' found in '%s' directory", opt.fileSuffix, dir)
+	return cases
+}
+
+// listCases is an internal function that walks the directory
+// and returns the cases for all input data files found
+func listCases(dir string, opt *optionSet) ([]*testCase, error) {
+	cases, err := listCaseFile(dir, opt)
+	if cases != nil || err != nil {
+		return cases, err
 	}
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && !opt.recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(info.Name(), opt.fileSuffix) {
+			return nil
+		}
+
+		c, err := newFileCase(dir, path, opt)
+		if err != nil {
+			return err
+		}
+		cases = append(cases, c)
+		return nil
+	})
+	return cases, err
 }
 
-// processFile is an internal function that deals with one source test file at a time
-func processFile(t *testing.T, path string, test Test, opt *optionSet) {
-	var referenceOutput []byte
+// newFileCase is an internal function that returns the case
+// for the input data file found in the directory
+func newFileCase(dir, path string, opt *optionSet) (*testCase, error) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return nil, err
+	}
+	c := &testCase{
+		name:       filepath.ToSlash(rel),
+		path:       path,
+		resultPath: path + opt.resultSuffix,
+	}
+	if opt.sanitize {
+		c.name = sanitizeName(c.name)
+		c.resultPath = filepath.Join(dir, filepath.FromSlash(c.name)) + opt.resultSuffix
+	}
+	if root := resultRoot(dir, opt); root != dir {
+		c.resultPath = filepath.Join(root, filepath.FromSlash(c.name)) + opt.resultSuffix
+	}
+	return c, nil
+}
 
-	var resultPath = path + opt.resultSuffix
+// processFile is an internal function that deals with one source test file at a time
+func processFile(t reporter, c *testCase, test Test, opt *optionSet) {
+	var path = c.path
 
 	// read JSON with test data
 
-	t.Log(path)
-	input, err := ioutil.ReadFile(path)
+	logf(t, opt, logInfo, "%s", path)
+	input, err := c.readInput(opt)
 	if err != nil {
-		t.Fatalf("Can't read the file: %v", err)
+		t.Errorf("Can't read the file: %v", err)
+		return
+	}
+	c.size = len(input)
+	if opt.allureDir != "" {
+		c.input = append([]byte(nil), input...)
 	}
 
-	if !opt.initMode {
-		// test mode: read reference results
+	// perform the actual test computation
 
-		if _, err := os.Stat(resultPath); os.IsNotExist(err) {
-			t.Fatalf("File '%s' doesn't exist (try initializing snapshots with 'go test -args init')", resultPath)
-		}
+	env, err := caseEnv(c, opt)
+	if err != nil {
+		t.Errorf("Can't read the environment of the case: %v", err)
+		return
+	}
 
-		referenceOutput, err = ioutil.ReadFile(resultPath)
-		if err != nil {
-			t.Fatalf("Can't read the '%s' file: %v", resultPath, err)
-		}
+	var original []byte
+	if opt.determinismRuns > 1 {
+		original = append([]byte(nil), input...)
 	}
 
-	// perform the actual test computation
+	var output []byte
+	start := time.Now()
+	withEnv(env, func() {
+		output, err = callTestTimeout(test, path, input, opt)
+	})
+	c.started, c.duration = start, time.Since(start)
+	logf(t, opt, logDebug, "Test function finished in %v", c.duration)
+	if opt.determinismRuns > 1 && !checkDeterminism(t, test, path, original, env, output, err, opt) {
+		return
+	}
+	if opt.errorSnapshots {
+		if checkError(t, c, err, opt) {
+			return
+		}
+	} else if err != nil {
+		t.Errorf("Error during test() call: %v", describeError(err))
+		switch err.(type) {
+		case *panicError, *timeoutError:
+			return
+		}
+	}
 
-	output, err := test(path, input)
+	output, err = transformOutput(path, output, opt)
 	if err != nil {
-		t.Errorf("Error during test() call: %v", err)
+		t.Errorf("Can't transform the output: %v", err)
+		return
+	}
+	if opt.allureDir != "" {
+		c.output = output
 	}
 
-	// marshal the result of the computation
+	if opt.schemaPath != "" {
+		violations, err := checkSchema(opt.schemaPath, output)
+		if err != nil {
+			t.Errorf("Can't validate the output: %v", err)
+		} else if len(violations) > 0 {
+			t.Errorf("Output doesn't match the schema '%s':\n\n%s\n", opt.schemaPath, strings.Join(violations, "\n"))
+		}
+	}
+
+	if opt.hashOnly {
+		checkHash(t, c.resultPath, output, opt)
+		return
+	}
+	if opt.pending {
+		checkPending(t, c.resultPath, output, opt)
+		return
+	}
+	checkResult(t, c.resultPath, output, opt)
+}
 
+// checkResult is an internal function that compares the generated output
+// with the reference result file in test mode, or saves it in init mode
+func checkResult(t reporter, resultPath string, output []byte, opt *optionSet) {
+	registerDir(filepath.Dir(resultPath))
 	if !opt.initMode {
-		// test mode: compare result with the reference data
-		// and print the diff when the test fails
+		// test mode: read reference results, compare them
+		// with the generated output and print the diff when the test fails
+
+		referenceOutput, err := readFile(resultPath, opt)
+		if os.IsNotExist(err) {
+			mismatchReporter(t, opt).Errorf("File '%s' doesn't exist (try initializing snapshots with 'go test -args init')", resultPath)
+			if opt.patch != nil {
+				opt.patch.record(resultPath, nil, output, true)
+			}
+			return
+		}
+		if err != nil {
+			t.Errorf("Can't read the '%s' file: %v", resultPath, err)
+			return
+		}
+		if err := verifyReference(t, resultPath, referenceOutput, opt); err != nil {
+			t.Errorf("Can't use the '%s' file: %v", resultPath, err)
+			return
+		}
 
-		if !bytes.Equal(output, referenceOutput) {
-			mainErrText := fmt.Sprintf("Reference %s contents don't match the generated output.", resultPath)
+		header, payload := splitAnnotations(referenceOutput, opt)
 
-			if opt.serializeFunc == nil {
-				t.Errorf("%s Also, no data serialization function provided; can't render a diff.", mainErrText)
-				return
+		logf(t, opt, logDebug, "Comparing the output with '%s'", resultPath)
+		t = mismatchReporter(t, opt)
+		if opt.patch != nil {
+			fc := &failureCounter{reporter: t}
+			compareFile(fc, resultPath, header, payload, output, opt)
+			if fc.failures > 0 {
+				opt.patch.record(resultPath, referenceOutput, append(header, output...), false)
 			}
+			return
+		}
+		compareFile(t, resultPath, header, payload, output, opt)
+	} else {
+		// init mode: save reference data
 
-			refStr, refErr := opt.serializeFunc(referenceOutput)
-			if refErr != nil {
-				t.Errorf("%s Also, serializing reference output data failed: %v",
-					mainErrText, refErr)
-				return
-			}
+		output = preserveAnnotations(resultPath, output, opt)
 
-			outStr, outErr := opt.serializeFunc(output)
-			if outErr != nil {
-				t.Errorf("%s Also, serializing generated output data failed: %v",
-					mainErrText, outErr)
-				return
-			}
+		if unchanged(resultPath, output, opt) {
+			logf(t, opt, logDebug, "Skipping unchanged file '%s'", resultPath)
+			return
+		}
 
-			diff := difflib.UnifiedDiff{
-				A:        difflib.SplitLines(refStr),
-				B:        difflib.SplitLines(outStr),
-				FromFile: resultPath + " (reference)",
-				ToFile:   resultPath + " (generated)",
-				Context:  3,
-				Colored:  true,
-			}
-			text, err := difflib.GetUnifiedDiffString(diff)
-			if err != nil {
-				t.Errorf("%s Also, generating the diff failed: %v",
-					mainErrText, err)
-				return
+		logf(t, opt, logInfo, "Writing file '%s'", resultPath)
+		err := writeFile(resultPath, output, opt)
+		if err != nil {
+			t.Errorf("Can't save file: %v", err)
+		}
+	}
+}
+
+// compareOutput is an internal function that compares the generated output
+// with the reference data and reports the differences
+func compareOutput(t reporter, resultPath string, referenceOutput, output []byte, opt *optionSet) {
+	if tool := diffTool(opt); tool != "" {
+		fc := &failureCounter{reporter: t}
+		defer func() {
+			if fc.failures > 0 {
+				runDiffTool(fc.reporter, tool, resultPath, referenceOutput, output, opt)
 			}
+		}()
+		t = fc
+	}
+
+	referenceOutput, output = normalize(referenceOutput, output, opt)
 
-			t.Errorf("%s Here's the diff:\n\n%s\n", mainErrText, text)
+	if opt.compareFunc != nil {
+		report, err := opt.compareFunc(referenceOutput, output)
+		if err != nil {
+			t.Errorf("Comparing reference %s contents with the generated output failed: %v",
+				resultPath, err)
+			return
+		}
+
+		if report != "" {
+			t.Errorf("Reference %s contents don't match the generated output. Here's the report:\n\n%s\n",
+				resultPath, report)
+		}
+		return
+	}
+
+	if !bytes.Equal(output, referenceOutput) {
+		mainErrText := fmt.Sprintf("Reference %s contents don't match the generated output.", resultPath)
+
+		if opt.serializeFunc == nil {
+			t.Errorf("%s Also, no data serialization function provided; can't render a diff.", mainErrText)
+			return
+		}
+
+		refStr, refErr := opt.serializeFunc(referenceOutput)
+		if refErr != nil {
+			t.Errorf("%s Also, serializing reference output data failed: %v",
+				mainErrText, refErr)
+			return
 		}
-	} else {
-		// init mode: save reference data
 
-		t.Logf("Writing file '%s'", resultPath)
-		err = ioutil.WriteFile(resultPath, output, 0644)
+		outStr, outErr := opt.serializeFunc(output)
+		if outErr != nil {
+			t.Errorf("%s Also, serializing generated output data failed: %v",
+				mainErrText, outErr)
+			return
+		}
+
+		diff := difflib.UnifiedDiff{
+			A:        difflib.SplitLines(refStr),
+			B:        difflib.SplitLines(outStr),
+			FromFile: resultPath + " (reference)",
+			ToFile:   resultPath + " (generated)",
+			Context:  3,
+			Colored:  true,
+		}
+		text, err := difflib.GetUnifiedDiffString(diff)
 		if err != nil {
-			t.Fatalf("Can't save file: %v", err)
+			t.Errorf("%s Also, generating the diff failed: %v",
+				mainErrText, err)
+			return
 		}
+
+		t.Errorf("%s Here's the diff:\n\n%s\n", mainErrText, text)
 	}
 }
@@ -0,0 +1,175 @@
+package agenda
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// AllureResults allows you to specify the directory where a result file
+// in the Allure format is written for each case after the run, so that
+// snapshot tests can be browsed in Allure reports (`allure serve <dir>`).
+// The input data, the reference data, the generated output and the diff
+// between the latter two are added to the result as attachments.
+// The directory is created if it doesn't exist; existing files are kept,
+// so that several Run calls can share it.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.AllureResults("allure-results"))
+func AllureResults(dir string) option {
+	return func(o *optionSet) {
+		o.allureDir = dir
+	}
+}
+
+// allureResult is an internal structure that represents
+// the "<uuid>-result.json" file of the Allure results directory
+type allureResult struct {
+	UUID          string             `json:"uuid"`
+	HistoryID     string             `json:"historyId"`
+	Name          string             `json:"name"`
+	FullName      string             `json:"fullName"`
+	Status        string             `json:"status"`
+	StatusDetails *allureDetails     `json:"statusDetails,omitempty"`
+	Stage         string             `json:"stage"`
+	Start         int64              `json:"start"`
+	Stop          int64              `json:"stop"`
+	Labels        []allureLabel      `json:"labels"`
+	Attachments   []allureAttachment `json:"attachments"`
+}
+
+type allureDetails struct {
+	Message string `json:"message"`
+}
+
+type allureLabel struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type allureAttachment struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Type   string `json:"type"`
+}
+
+// writeAllure is an internal function that writes
+// the Allure results of the cases (see AllureResults())
+func writeAllure(t *testing.T, cases []*testCase, opt *optionSet) {
+	if err := os.MkdirAll(opt.allureDir, 0755); err != nil {
+		t.Errorf("Can't create the Allure results directory: %v", err)
+		return
+	}
+	for _, c := range cases {
+		if err := writeAllureResult(t.Name(), c, opt); err != nil {
+			t.Errorf("Can't save the Allure result of '%s': %v", c.path, err)
+			return
+		}
+	}
+}
+
+// writeAllureResult is an internal function that writes the result file
+// of the case, along with its attachments
+func writeAllureResult(suite string, c *testCase, opt *optionSet) error {
+	fullName := suite + "/" + c.name
+	id := sha1.Sum([]byte(fullName))
+	r := &allureResult{
+		UUID:      newUUID(),
+		HistoryID: hex.EncodeToString(id[:]),
+		Name:      c.name,
+		FullName:  fullName,
+		Stage:     "finished",
+		Labels: []allureLabel{
+			{"suite", suite},
+			{"framework", "agenda"},
+			{"language", "go"},
+		},
+		Attachments: []allureAttachment{},
+	}
+	for _, tag := range c.tags {
+		r.Labels = append(r.Labels, allureLabel{"tag", tag})
+	}
+	if !c.started.IsZero() {
+		r.Start = c.started.UnixNano() / 1e6
+		r.Stop = c.started.Add(c.duration).UnixNano() / 1e6
+	}
+
+	switch c.status(opt) {
+	case StatusSkipped:
+		r.Status = "skipped"
+		if c.skip != "" {
+			r.StatusDetails = &allureDetails{Message: c.skip}
+		}
+	case StatusFailed:
+		r.Status = "failed"
+		r.StatusDetails = &allureDetails{Message: strings.Join(c.failures, "\n")}
+	default:
+		r.Status = "passed"
+	}
+
+	attach := func(name string, data []byte) error {
+		ext, mimeType := attachmentType(data)
+		source := r.UUID + "-attachment-" + fmt.Sprint(len(r.Attachments)+1) + ext
+		if err := ioutil.WriteFile(filepath.Join(opt.allureDir, source), data, 0644); err != nil {
+			return err
+		}
+		r.Attachments = append(r.Attachments, allureAttachment{name, source, mimeType})
+		return nil
+	}
+
+	if c.input != nil {
+		if err := attach("input", c.input); err != nil {
+			return err
+		}
+	}
+	reference, err := readFile(c.resultPath, opt)
+	if err == nil && c.ran && !opt.initMode {
+		if err := attach("expected", reference); err != nil {
+			return err
+		}
+	}
+	if c.output != nil {
+		if err := attach("actual", c.output); err != nil {
+			return err
+		}
+	}
+	if err == nil && c.failed && c.output != nil && !bytes.Equal(reference, c.output) {
+		diff := unifiedPatch(filepath.ToSlash(c.resultPath), reference, c.output, false)
+		if err := attach("diff", []byte(diff)); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(opt.allureDir, r.UUID+"-result.json"), data, 0644)
+}
+
+// attachmentType is an internal function that returns
+// the file extension and the MIME type of the attachment
+func attachmentType(data []byte) (string, string) {
+	if len(data) > 0 && json.Valid(data) {
+		return ".json", "application/json"
+	}
+	return ".txt", "text/plain"
+}
+
+// newUUID is an internal function that returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,65 @@
+package agenda
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAllureResults is a traditional (non agenda-based) test
+// that checks the Allure results written for each case
+func TestAllureResults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// failures are reported in shadow mode, so that this test doesn't fail
+	Run(t, "testdata/shadow", test01, Shadow(nil), AllureResults(dir))
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*-result.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r allureResult
+		if err := json.Unmarshal(data, &r); err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, a := range r.Attachments {
+			if _, err := os.Stat(filepath.Join(dir, a.Source)); err != nil {
+				t.Errorf("Missing attachment of %s: %v", r.Name, err)
+			}
+			names = append(names, a.Name)
+		}
+		got[r.Name] = r.Status + " " + strings.Join(names, ",")
+		if r.FullName != t.Name()+"/"+r.Name {
+			t.Errorf("Unexpected full name: %s", r.FullName)
+		}
+	}
+
+	want := map[string]string{
+		"1.json": "passed input,expected,actual",
+		"2.json": "failed input,expected,actual,diff",
+		"3.json": "failed input,actual",
+	}
+	for name, result := range want {
+		if got[name] != result {
+			t.Errorf("Expected '%s' for %s, got '%s'", result, name, got[name])
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("Unexpected results: %v", got)
+	}
+}
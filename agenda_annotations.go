@@ -0,0 +1,61 @@
+package agenda
+
+import (
+	"bytes"
+)
+
+// Annotations allows reviewers to keep notes in the result files:
+// the lines at the beginning of a result file that start with `prefix`
+// (e.g. "#") are ignored when the file is compared with the generated
+// output, and are preserved when the file is rewritten in initialization
+// mode, so that the notes on tricky cases survive the regeneration.
+// Choose a prefix the generated output itself never starts with.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Annotations("#"))
+func Annotations(prefix string) option {
+	if prefix == "" {
+		panic("annotation prefix is empty")
+	}
+	return func(o *optionSet) {
+		o.annotationPrefix = prefix
+	}
+}
+
+// splitAnnotations is an internal function that splits the contents
+// of the result file into the annotation header and the reference data
+func splitAnnotations(data []byte, opt *optionSet) ([]byte, []byte) {
+	if opt.annotationPrefix == "" {
+		return nil, data
+	}
+
+	prefix := []byte(opt.annotationPrefix)
+	n := 0
+	for bytes.HasPrefix(data[n:], prefix) {
+		end := bytes.IndexByte(data[n:], '\n')
+		if end < 0 {
+			n = len(data)
+			break
+		}
+		n += end + 1
+	}
+	return data[:n:n], data[n:]
+}
+
+// preserveAnnotations is an internal function that prepends
+// the annotation header of the existing result file to the output
+func preserveAnnotations(resultPath string, output []byte, opt *optionSet) []byte {
+	if opt.annotationPrefix == "" {
+		return output
+	}
+	existing, err := readFile(resultPath, opt)
+	if err != nil {
+		return output
+	}
+	header, _ := splitAnnotations(existing, opt)
+	if len(header) == 0 {
+		return output
+	}
+	return append(append([]byte(nil), header...), output...)
+}
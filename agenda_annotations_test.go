@@ -0,0 +1,40 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAnnotations is a traditional (non agenda-based) test that checks
+// that the annotations of the result files are ignored in comparison
+// and preserved on regeneration
+func TestAnnotations(t *testing.T) {
+	dir := copyDir(t, "testdata/01/default")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "1.json.result")
+	reference, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := "# division result is rounded\n# see the spec\n"
+	if err := ioutil.WriteFile(path, append([]byte(notes), reference...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	Run(t, dir, test01, Annotations("#"), InitMode(false))
+
+	Run(t, dir, func(path string, data []byte) ([]byte, error) {
+		return []byte("{}"), nil
+	}, Annotations("#"), InitMode(true))
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := notes + "{}"; string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+}
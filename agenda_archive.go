@@ -0,0 +1,198 @@
+package agenda
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Strum355/go-difflib/difflib"
+)
+
+// ArchiveComparator is a shortcut option that sets the comparator function
+// for zip, tar and tar.gz outputs, which compares the archive entries
+// by name and content, ignoring the archive-level metadata (timestamps,
+// permissions, compression) and the order of entries. Added, removed
+// and changed entries are reported; for changed text entries, the diff
+// is included.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.ArchiveComparator())
+func ArchiveComparator() option {
+	return Comparator(compareArchives)
+}
+
+// ArchiveSerializer is a shortcut option that sets the serializer function
+// which renders zip, tar and tar.gz archives as the sorted list of entries
+// with their sizes and checksums, followed by the contents of text entries
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.ArchiveSerializer())
+func ArchiveSerializer() option {
+	return Serializer(serializeArchive)
+}
+
+// compareArchives is an internal function that compares
+// the entries of two archives (see ArchiveComparator())
+func compareArchives(reference, output []byte) (string, error) {
+	if bytes.Equal(reference, output) {
+		return "", nil
+	}
+
+	want, err := readArchive(reference)
+	if err != nil {
+		return "", fmt.Errorf("can't read reference archive: %v", err)
+	}
+	got, err := readArchive(output)
+	if err != nil {
+		return "", fmt.Errorf("can't read generated archive: %v", err)
+	}
+
+	var lines []string
+	for _, name := range entryNames(want, got) {
+		w, inWant := want[name]
+		g, inGot := got[name]
+		switch {
+		case !inGot:
+			lines = append(lines, fmt.Sprintf("removed entry %s (%d bytes)", name, len(w)))
+		case !inWant:
+			lines = append(lines, fmt.Sprintf("added entry %s (%d bytes)", name, len(g)))
+		case !bytes.Equal(w, g):
+			lines = append(lines, fmt.Sprintf("changed entry %s (%d -> %d bytes)", name, len(w), len(g)))
+			if isText(w) && isText(g) {
+				diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+					A:        difflib.SplitLines(string(w)),
+					B:        difflib.SplitLines(string(g)),
+					FromFile: name + " (reference)",
+					ToFile:   name + " (generated)",
+					Context:  3,
+				})
+				if err == nil {
+					lines = append(lines, diff)
+				}
+			}
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// serializeArchive is an internal function that renders
+// the entries of the archive (see ArchiveSerializer())
+func serializeArchive(data []byte) (string, error) {
+	entries, err := readArchive(data)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, name := range entryNames(entries) {
+		content := entries[name]
+		fmt.Fprintf(&b, "%s (%d bytes, sha256 %x)\n", name, len(content), sha256.Sum256(content))
+		if len(content) > 0 && isText(content) {
+			for _, line := range strings.SplitAfter(string(content), "\n") {
+				if line != "" {
+					b.WriteString("    " + strings.TrimSuffix(line, "\n") + "\n")
+				}
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// readArchive is an internal function that reads the regular file entries
+// of the zip, tar or tar.gz archive, detected by its contents
+func readArchive(data []byte) (map[string][]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return readZip(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return readTar(r)
+	case len(data) > 262 && string(data[257:262]) == "ustar":
+		return readTar(bytes.NewReader(data))
+	}
+	return nil, errors.New("unknown archive format")
+}
+
+func readZip(data []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string][]byte)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries[f.Name] = content
+	}
+	return entries, nil
+}
+
+func readTar(r io.Reader) (map[string][]byte, error) {
+	tr := tar.NewReader(r)
+
+	entries := make(map[string][]byte)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg && h.Typeflag != tar.TypeRegA {
+			continue
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		entries[h.Name] = content
+	}
+}
+
+// entryNames returns the sorted union of the entry names
+func entryNames(archives ...map[string][]byte) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, entries := range archives {
+		for name := range entries {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isText returns true if the data looks like text
+func isText(data []byte) bool {
+	return utf8.Valid(data) && bytes.IndexByte(data, 0) < 0
+}
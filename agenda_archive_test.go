@@ -0,0 +1,104 @@
+package agenda
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+)
+
+type archiveEntry struct {
+	name    string
+	content string
+}
+
+func makeZip(t *testing.T, modified time.Time, entries ...archiveEntry) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(e.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func makeTarGz(t *testing.T, modified time.Time, entries ...archiveEntry) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.content)), ModTime: modified}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(e.content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestCompareArchives is a traditional (non agenda-based) test
+// that compares archives which differ in metadata and contents
+func TestCompareArchives(t *testing.T) {
+	a := archiveEntry{"a.txt", "alpha\n"}
+	b := archiveEntry{"dir/b.txt", "beta\n"}
+	c := archiveEntry{"c.bin", "\x00\x01"}
+	then, now := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), time.Now()
+
+	for _, make := range []func(*testing.T, time.Time, ...archiveEntry) []byte{makeZip, makeTarGz} {
+		report, err := compareArchives(make(t, then, a, b), make(t, now, b, a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report != "" {
+			t.Errorf("Expected no differences, got:\n%s", report)
+		}
+
+		report, err = compareArchives(make(t, then, a, b), make(t, now, archiveEntry{"a.txt", "ALPHA\n"}, c))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(report, "\n")
+		if lines[0] != "changed entry a.txt (6 -> 6 bytes)" ||
+			!strings.Contains(report, "\nadded entry c.bin (2 bytes)") ||
+			!strings.HasSuffix(report, "\nremoved entry dir/b.txt (5 bytes)") {
+			t.Errorf("Unexpected report:\n%s", report)
+		}
+	}
+
+	if _, err := compareArchives([]byte("not an archive"), makeZip(t, now, a)); err == nil {
+		t.Errorf("Expected an error for unknown archive format")
+	}
+}
+
+// TestSerializeArchive is a traditional (non agenda-based) test
+// that checks the rendering of the archive entries
+func TestSerializeArchive(t *testing.T) {
+	data := makeTarGz(t, time.Now(), archiveEntry{"b.txt", "line 1\nline 2"}, archiveEntry{"a.bin", "\x00"})
+
+	s, err := serializeArchive(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a.bin (1 bytes, sha256 6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d)\n" +
+		"b.txt (13 bytes, sha256 e266782c2841ee29d8f5aafc686abc45e9f119998a6b5c12fcbba5b19010cba4)\n" +
+		"    line 1\n" +
+		"    line 2\n"
+	if s != want {
+		t.Errorf("Unexpected rendering:\n%s\nwant:\n%s", s, want)
+	}
+}
@@ -0,0 +1,160 @@
+package agenda
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// cacheMu serializes access to cache files
+// from Run calls executed in parallel
+var cacheMu sync.Mutex
+
+// caseCache is an internal structure that represents the contents
+// of the cache file: the keys of the cases that passed the last time,
+// keyed by their result file path
+type caseCache struct {
+	Cases map[string]string `json:"cases"`
+}
+
+// Cache allows you to specify the path to the file where the cases which
+// passed are recorded, so that on repeated runs the cases whose input data,
+// environment and reference files haven't changed since are skipped,
+// which speeds up the iterative development with large corpora. `version`
+// must change whenever the code under test (or the options) changes in
+// a way that can affect the output, e.g. it can be the hash of the source
+// files or the VCS revision. The cache is not used in initialization mode,
+// and is not supported by RunGroups.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Cache("testdata/.cache.json", codeVersion))
+func Cache(path, version string) option {
+	return func(o *optionSet) {
+		o.cachePath = path
+		o.cacheVersion = version
+	}
+}
+
+// readCache is an internal function that reads the cache file;
+// a missing file results in an empty cache
+func readCache(path string) (*caseCache, error) {
+	cache := &caseCache{Cases: make(map[string]string)}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, err
+	}
+	if cache.Cases == nil {
+		cache.Cases = make(map[string]string)
+	}
+	return cache, nil
+}
+
+// cacheKey is an internal function that returns the digest of everything
+// the outcome of the case depends on: the code version, the input data
+// passed to the test function (which includes the included files and
+// the template data, see Includes() and Template()), the environment
+// and the reference files
+func cacheKey(c *testCase, opt *optionSet) (string, error) {
+	data := []byte(opt.cacheVersion + "\x00")
+
+	input, err := c.readInput(opt)
+	if err != nil {
+		return "", err
+	}
+	data = append(data, digest(input)...)
+
+	env, err := caseEnv(c, opt)
+	if err != nil {
+		return "", err
+	}
+	var vars []string
+	for k, v := range env {
+		vars = append(vars, k+"="+v)
+	}
+	sort.Strings(vars)
+	data = append(data, digest([]byte(strings.Join(vars, "\x00")))...)
+
+	for _, path := range []string{c.resultPath, c.errorPath(opt)} {
+		content, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		data = append(data, '\x00')
+		if err == nil {
+			data = append(data, digest(content)...)
+		}
+	}
+	return digest(data), nil
+}
+
+// cachedKeys is an internal function that computes the cache keys
+// of the cases, and returns them along with the set of cases
+// that can be skipped
+func cachedKeys(t *testing.T, cases []*testCase, opt *optionSet) (map[*testCase]string, map[*testCase]bool) {
+	cacheMu.Lock()
+	cache, err := readCache(opt.cachePath)
+	cacheMu.Unlock()
+	if err != nil {
+		t.Errorf("Can't read the cache file: %v", err)
+		return nil, nil
+	}
+
+	keys := make(map[*testCase]string)
+	cached := make(map[*testCase]bool)
+	for _, c := range cases {
+		key, err := cacheKey(c, caseOptions(c, opt))
+		if err != nil {
+			continue
+		}
+		keys[c] = key
+		cached[c] = cache.Cases[c.resultPath] == key
+	}
+	return keys, cached
+}
+
+// updateCache is an internal function that records the cases which passed
+// into the cache file, and removes the ones which failed or had mismatches
+// reported as warnings (see WarnOnly())
+func updateCache(t *testing.T, cases []*testCase, keys map[*testCase]string, opt *optionSet) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	cache, err := readCache(opt.cachePath)
+	if err != nil {
+		t.Errorf("Can't read the cache file: %v", err)
+		return
+	}
+
+	for _, c := range cases {
+		if !c.ran {
+			continue
+		}
+		if key, ok := keys[c]; ok && !c.failed && !c.warned {
+			cache.Cases[c.resultPath] = key
+		} else {
+			delete(cache.Cases, c.resultPath)
+		}
+	}
+
+	data, err := json.MarshalIndent(cache, "", "\t")
+	if err != nil {
+		t.Errorf("Can't serialize the cache: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(opt.cachePath, data, 0644); err != nil {
+		t.Errorf("Can't save the cache file: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package agenda
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCache is a traditional (non agenda-based) test that checks
+// that only the changed cases are run again
+func TestCache(t *testing.T) {
+	dir := copyDir(t, "testdata/01/default")
+	defer os.RemoveAll(dir)
+	cacheDir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	cachePath := filepath.Join(cacheDir, "cache.json")
+
+	countRuns := func(version string) int {
+		runs := 0
+		Run(t, dir, func(path string, data []byte) ([]byte, error) {
+			runs++
+			return test01(path, data)
+		}, Cache(cachePath, version))
+		return runs
+	}
+
+	if n := countRuns("v1"); n != 4 {
+		t.Errorf("Expected 4 cases to run the first time, got %d", n)
+	}
+	if n := countRuns("v1"); n != 0 {
+		t.Errorf("Expected all cases to be cached, got %d runs", n)
+	}
+
+	// change the input data of one case (keeping its meaning)
+	path := filepath.Join(dir, "1.json")
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRuns("v1"); n != 1 {
+		t.Errorf("Expected only the changed case to run, got %d runs", n)
+	}
+
+	if n := countRuns("v2"); n != 4 {
+		t.Errorf("Expected all cases to run for a new version, got %d", n)
+	}
+}
+
+// TestCacheWarnOnly is a traditional (non agenda-based) test that checks
+// that the cases with warnings are not cached in warn-only mode
+func TestCacheWarnOnly(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	cachePath := filepath.Join(cacheDir, "cache.json")
+
+	countRuns := func() int {
+		runs := 0
+		Run(t, "testdata/shadow", func(path string, data []byte) ([]byte, error) {
+			runs++
+			return test01(path, data)
+		}, Cache(cachePath, "v1"), WarnOnly())
+		return runs
+	}
+
+	if n := countRuns(); n != 3 {
+		t.Errorf("Expected 3 cases to run the first time, got %d", n)
+	}
+	if n := countRuns(); n != 2 {
+		t.Errorf("Expected the cases with warnings to run again, got %d runs", n)
+	}
+}
+
+// TestCacheObservers is a traditional (non agenda-based) test that checks
+// that the cached cases are reported to the observers and the progress
+func TestCacheObservers(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	cachePath := filepath.Join(cacheDir, "cache.json")
+
+	Run(t, "testdata/01/default", test01, Cache(cachePath, "v1"))
+
+	o := &recordingObserver{}
+	var buf bytes.Buffer
+	l := log.New(&buf, "", 0)
+	Run(t, "testdata/01/default", test01, Cache(cachePath, "v1"), Observe(o), Progress(4, 0), Logger(l))
+	want := []string{
+		"start 4",
+		"pass testdata/01/default/1.json",
+		"pass testdata/01/default/2.json",
+		"pass testdata/01/default/3.json",
+		"pass testdata/01/default/4.json",
+	}
+	if strings.Join(o.events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected events:\n%s", strings.Join(o.events, "\n"))
+	}
+	if !strings.Contains(buf.String(), "Processed 4/4 cases (100%)") {
+		t.Errorf("Expected the progress to reach all cases, got:\n%s", buf.String())
+	}
+}
+
+// TestCacheIncludes is a traditional (non agenda-based) test that checks
+// that the cases are run again when the files they include change
+func TestCacheIncludes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cachePath := filepath.Join(dir, "cache.json")
+	dataDir := filepath.Join(dir, "data")
+	commonPath := filepath.Join(dataDir, "common", "user.json")
+
+	if err := os.MkdirAll(filepath.Dir(commonPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dataDir, "1.json"), []byte(`{"user": {"$include": "common/user.json"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(commonPath, []byte(`{"name": "alice"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	Run(t, dataDir, testJSONC, Includes(), InitMode(true))
+
+	countRuns := func() int {
+		runs := 0
+		// failures are reported in shadow mode, so that this test doesn't fail
+		Run(t, dataDir, func(path string, data []byte) ([]byte, error) {
+			runs++
+			return testJSONC(path, data)
+		}, Includes(), Cache(cachePath, "v1"), Shadow(nil))
+		return runs
+	}
+
+	if n := countRuns(); n != 1 {
+		t.Errorf("Expected the case to run the first time, got %d runs", n)
+	}
+	if n := countRuns(); n != 0 {
+		t.Errorf("Expected the case to be cached, got %d runs", n)
+	}
+	if err := ioutil.WriteFile(commonPath, []byte(`{"name": "bob"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRuns(); n != 1 {
+		t.Errorf("Expected the case to run after the included file changed, got %d runs", n)
+	}
+}
+
+// TestCacheOutput is a traditional (non agenda-based) test that checks
+// that the cache file is written to disk regardless of the output options
+func TestCacheOutput(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	cachePath := filepath.Join(cacheDir, "cache.json")
+
+	countRuns := func() int {
+		runs := 0
+		Run(t, "testdata/01/default", func(path string, data []byte) ([]byte, error) {
+			runs++
+			return test01(path, data)
+		}, Cache(cachePath, "v1"), Output(NewMemFS()), ConfineWrites())
+		return runs
+	}
+
+	if n := countRuns(); n != 4 {
+		t.Errorf("Expected 4 cases to run the first time, got %d", n)
+	}
+	if n := countRuns(); n != 0 {
+		t.Errorf("Expected all cases to be cached, got %d runs", n)
+	}
+}
@@ -0,0 +1,134 @@
+package agenda
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// caseListName is the name of the optional file in the test directory
+// which enumerates the cases explicitly (see listCaseFile())
+const caseListName = "cases.json"
+
+// caseList describes the contents of the "cases.json" file
+type caseList struct {
+	Cases []caseSpec `json:"cases"`
+}
+
+// caseSpec describes a single case listed in the "cases.json" file
+type caseSpec struct {
+	Name    string            `json:"name"`    // subtest name; defaults to the input file path
+	Input   string            `json:"input"`   // input file path, relative to the test directory
+	Result  string            `json:"result"`  // result file path; defaults to the input file path with the result suffix
+	Tags    []string          `json:"tags"`    // tags to select the case by (see Tags())
+	Env     map[string]string `json:"env"`     // environment variables to set around the test function call
+	Skip    string            `json:"skip"`    // if not empty, the case is skipped with this reason
+	Timeout string            `json:"timeout"` // overrides the Timeout() option (e.g. "5s")
+	Retries *int              `json:"retries"` // overrides the RetryFailedCases() option
+	Schema  string            `json:"schema"`  // overrides the OutputSchema() option
+}
+
+// Tags allows you to run only the cases listed in the "cases.json" file
+// which have at least one of the specified tags.
+//
+// The optional "cases.json" file in the test directory gives explicit control
+// over which files are cases (all other files, even the ones with the matching
+// suffix, are treated as shared fixtures), and allows to set per-case options:
+//
+//		{"cases": [
+//			{"input": "01.json", "tags": ["fast"]},
+//			{"name": "tz", "input": "02.json", "env": {"TZ": "UTC"}, "timeout": "5s", "retries": 2},
+//			{"input": "03.json", "result": "golden/03.out", "skip": "flaky, see #123"}
+//		]}
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Tags("fast"))
+func Tags(tags ...string) option {
+	return func(o *optionSet) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// listCaseFile is an internal function that returns the cases listed
+// in the "cases.json" file of the directory, or nil if there is no such file
+func listCaseFile(dir string, opt *optionSet) ([]*testCase, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, caseListName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list caseList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("can't parse '%s': %v", caseListName, err)
+	}
+
+	cases := make([]*testCase, 0, len(list.Cases))
+	for _, spec := range list.Cases {
+		if spec.Input == "" {
+			return nil, fmt.Errorf("case '%s' in '%s' has no input file", spec.Name, caseListName)
+		}
+
+		c := &testCase{
+			name:       spec.Name,
+			path:       filepath.Join(dir, filepath.FromSlash(spec.Input)),
+			resultPath: filepath.Join(resultRoot(dir, opt), filepath.FromSlash(spec.Input)) + opt.resultSuffix,
+			tags:       spec.Tags,
+			env:        spec.Env,
+			skip:       spec.Skip,
+		}
+		if c.name == "" {
+			c.name = spec.Input
+		}
+		if spec.Result != "" {
+			c.resultPath = filepath.Join(resultRoot(dir, opt), filepath.FromSlash(spec.Result))
+		}
+		if spec.Timeout != "" {
+			d, err := time.ParseDuration(spec.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("case '%s' in '%s' has invalid timeout: %v", c.name, caseListName, err)
+			}
+			c.options = append(c.options, Timeout(d))
+		}
+		if spec.Retries != nil {
+			c.options = append(c.options, RetryFailedCases(*spec.Retries))
+		}
+		if spec.Schema != "" {
+			c.options = append(c.options, OutputSchema(filepath.Join(dir, filepath.FromSlash(spec.Schema))))
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// caseOptions is an internal function that returns the options
+// with the per-case overrides applied
+func caseOptions(c *testCase, opt *optionSet) *optionSet {
+	if len(c.options) == 0 {
+		return opt
+	}
+	o := *opt
+	for _, f := range c.options {
+		f(&o)
+	}
+	return &o
+}
+
+// hasTag is an internal function that returns true if the case
+// has at least one of the tags
+func hasTag(c *testCase, tags []string) bool {
+	for _, tag := range tags {
+		for _, t := range c.tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// TestCaseList runs agenda tests for the cases listed in the "cases.json" file
+func TestCaseList(t *testing.T) {
+	Run(t, "testdata/case-list", test01)
+}
+
+// TestCaseListTags is a traditional (non agenda-based) test
+// that checks the selection of cases by tags and the per-case options
+func TestCaseListTags(t *testing.T) {
+	res := RunWithResults(t, "testdata/case-list", test01, Tags("fast"))
+	if len(res.Cases) != 1 || res.Cases[0].Name != "first" {
+		t.Fatalf("Expected only the 'first' case to run, got %+v", res.Cases)
+	}
+
+	res = RunWithResults(t, "testdata/case-list", test01)
+	if len(res.Cases) != 3 {
+		t.Fatalf("Expected 3 cases, got %d", len(res.Cases))
+	}
+	if got := res.Cases[1].ResultPath; got != "testdata/case-list/2.out" {
+		t.Errorf("Expected custom result path, got '%s'", got)
+	}
+	if got := res.Cases[2].Status; got != StatusSkipped {
+		t.Errorf("Expected the case to be skipped, got '%s'", got)
+	}
+
+	cases, err := listCaseFile("testdata/case-list", newOptionSet(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := caseOptions(cases[1], newOptionSet(nil))
+	if opt.timeout.String() != "10s" || opt.caseRetries != 1 {
+		t.Errorf("Expected per-case overrides, got timeout=%v retries=%d", opt.timeout, opt.caseRetries)
+	}
+}
@@ -0,0 +1,39 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// InitGuard allows you to control the safety check which fails the test
+// when initialization mode is enabled with the "init" argument
+// (`go test -args init`) while running in CI, preventing a misconfigured
+// CI job from silently regenerating all result files and masking regressions.
+// When InitMode() is used, the decision is left to the test code.
+//
+// Default: enabled when the CI environment variable is set
+//
+// Example:
+//
+// // the CI job that refreshes the snapshots on purpose
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.InitGuard(false))
+func InitGuard(enabled bool) option {
+	return func(o *optionSet) {
+		o.initGuard = enabled
+	}
+}
+
+// guardInit is an internal function that fails the test if initialization
+// mode is not allowed (see InitGuard())
+func guardInit(t *testing.T, opt *optionSet) {
+	t.Helper()
+	if !initAllowed(opt) {
+		t.Fatalf("Initialization mode is not allowed in CI (the CI environment variable is set); " +
+			"use the InitGuard(false) option to allow it")
+	}
+}
+
+// initAllowed is an internal function that returns false if initialization
+// mode was enabled by the command-line argument while it is guarded
+func initAllowed(opt *optionSet) bool {
+	return !(opt.initGuard && opt.initMode && opt.initFlag)
+}
@@ -0,0 +1,30 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// TestInitGuard is a traditional (non agenda-based) test that checks
+// when initialization mode is considered to be accidental
+func TestInitGuard(t *testing.T) {
+	var tests = []struct {
+		options []option
+		blocked bool
+	}{
+		{[]option{}, true},
+		{[]option{InitGuard(false)}, false},
+		{[]option{InitMode(true)}, false},
+		{[]option{InitMode(false)}, false},
+	}
+
+	for _, test := range tests {
+		opt := newOptionSet(append([]option{InitGuard(true), func(o *optionSet) {
+			// simulate `go test -args init`
+			o.initMode, o.initFlag = true, true
+		}}, test.options...))
+
+		if blocked := !initAllowed(opt); blocked != test.blocked {
+			t.Errorf("Expected blocked=%v, got %v", test.blocked, blocked)
+		}
+	}
+}
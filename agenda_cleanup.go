@@ -0,0 +1,12 @@
+//go:build go1.14
+// +build go1.14
+
+package agenda
+
+import "testing"
+
+// onTestDone is an internal function that calls f
+// when the test and all its subtests finish
+func onTestDone(t *testing.T, f func()) {
+	t.Cleanup(f)
+}
@@ -0,0 +1,30 @@
+//go:build go1.14
+// +build go1.14
+
+package agenda
+
+import (
+	"testing"
+)
+
+// TestMatchForget checks that the snapshots taken in a test
+// are forgotten when it finishes
+func TestMatchForget(t *testing.T) {
+	var name string
+	t.Run("sub", func(t *testing.T) {
+		name = t.Name()
+		Snapshot(t, []byte("forget\n"))
+
+		matched.Lock()
+		defer matched.Unlock()
+		if matched.tests[name] == nil {
+			t.Errorf("Expected the snapshot to be tracked while the test runs")
+		}
+	})
+
+	matched.Lock()
+	defer matched.Unlock()
+	if m := matched.tests[name]; m != nil {
+		t.Errorf("Expected the finished test to be forgotten, got %v", m.paths)
+	}
+}
@@ -0,0 +1,38 @@
+package agenda
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// Compare compares the data with the single reference (golden) file,
+// for tests that already have their own loops but want the snapshot
+// semantics: output transformations and comparison options are applied,
+// the diff is reported on mismatch, and in initialization mode the data
+// is saved as the new reference.
+//
+// Example:
+//
+//		for _, tc := range cases {
+//			got := Render(tc.page)
+//			agenda.Compare(t, "testdata/render/"+tc.name+".html", got)
+//		}
+func Compare(t *testing.T, goldenPath string, got []byte, options ...option) {
+	opt := newOptionSet(options)
+	guardInit(t, opt)
+
+	compareGolden(t, goldenPath, got, opt)
+}
+
+// compareGolden is an internal function that transforms the data
+// and checks it against the golden file
+func compareGolden(t reporter, goldenPath string, got []byte, opt *optionSet) {
+	opt.roots = append(opt.roots, filepath.Dir(goldenPath))
+
+	got, err := transformOutput(goldenPath, got, opt)
+	if err != nil {
+		t.Errorf("Can't transform the output: %v", err)
+		return
+	}
+	checkResult(t, goldenPath, got, opt)
+}
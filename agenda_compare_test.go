@@ -0,0 +1,38 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCompare is a traditional (non agenda-based) test that checks
+// saving and comparing a single golden file
+func TestCompare(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nested", "golden.txt")
+	got := []byte("created at 2020-01-02T03:04:05Z\n")
+
+	Compare(t, path, got, InitMode(true), ScrubTimestamps())
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "created at <timestamp>\n" {
+		t.Errorf("Unexpected golden file contents: %q", data)
+	}
+
+	Compare(t, path, []byte("created at 2021-05-06T07:08:09Z\n"), InitMode(false), ScrubTimestamps())
+
+	var r fakeReporter
+	compareGolden(&r, path, []byte("changed\n"), newOptionSet([]option{InitMode(false)}))
+	if len(r.errors) != 1 {
+		t.Errorf("Expected a mismatch to be reported, got %v", r.errors)
+	}
+}
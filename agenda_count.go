@@ -0,0 +1,43 @@
+package agenda
+
+import (
+	"fmt"
+)
+
+// ExpectCases makes the test fail if the number of the discovered input
+// data files is not exactly `n`, to protect from a bad file suffix or
+// an accidental mass deletion silently shrinking the coverage.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.ExpectCases(120))
+func ExpectCases(n int) option {
+	return ExpectCasesBetween(n, n)
+}
+
+// ExpectCasesBetween is similar to ExpectCases, but allows the number
+// of the discovered input data files to be anywhere between `min` and `max`
+// (inclusive); 0 as `max` means there's no upper bound.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.ExpectCasesBetween(100, 0))
+func ExpectCasesBetween(min, max int) option {
+	return func(o *optionSet) {
+		o.expectCases = true
+		o.minCases = min
+		o.maxCases = max
+	}
+}
+
+// checkCaseCount is an internal function that returns an error if the number
+// of discovered cases is not within the expected bounds
+func checkCaseCount(n int, opt *optionSet) error {
+	switch {
+	case n < opt.minCases:
+		return fmt.Errorf("expected at least %d cases, found %d", opt.minCases, n)
+	case opt.maxCases > 0 && n > opt.maxCases:
+		return fmt.Errorf("expected at most %d cases, found %d", opt.maxCases, n)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// TestExpectCases runs agenda tests with the expected number of cases
+func TestExpectCases(t *testing.T) {
+	Run(t, "testdata/01/default", test01, ExpectCases(4))
+	Run(t, "testdata/01/default", test01, ExpectCasesBetween(1, 0))
+}
+
+// TestCheckCaseCount is a traditional (non agenda-based) test
+// that checks the bounds of the expected number of cases
+func TestCheckCaseCount(t *testing.T) {
+	tests := []struct {
+		n     int
+		opt   option
+		valid bool
+	}{
+		{2, ExpectCases(2), true},
+		{1, ExpectCases(2), false},
+		{3, ExpectCases(2), false},
+		{0, ExpectCasesBetween(0, 0), true},
+		{100, ExpectCasesBetween(10, 0), true},
+		{5, ExpectCasesBetween(10, 20), false},
+		{21, ExpectCasesBetween(10, 20), false},
+	}
+	for _, test := range tests {
+		err := checkCaseCount(test.n, newOptionSet([]option{test.opt}))
+		if (err == nil) != test.valid {
+			t.Errorf("Unexpected result for %d cases: %v", test.n, err)
+		}
+	}
+}
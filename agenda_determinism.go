@@ -0,0 +1,101 @@
+package agenda
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Strum355/go-difflib/difflib"
+)
+
+// CheckDeterminism makes the test function be called `n` times (at least 2)
+// for each case, and fails the case if the outputs (after the output
+// transformations) or the errors differ between the calls, to catch
+// nondeterministic output (e.g. map ordering, current time or randomness)
+// before it gets into the result files.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.CheckDeterminism(3))
+func CheckDeterminism(n int) option {
+	if n < 2 {
+		panic("the number of calls must be at least 2")
+	}
+	return func(o *optionSet) {
+		o.determinismRuns = n
+	}
+}
+
+// checkDeterminism is an internal function that calls the test function
+// again and compares the results with the ones of the first call.
+// It returns false if the results differ.
+func checkDeterminism(t reporter, test Test, path string, input []byte, env map[string]string, output []byte, testErr error, opt *optionSet) bool {
+	first := transformedOrRaw(path, output, opt)
+
+	for i := 2; i <= opt.determinismRuns; i++ {
+		var out []byte
+		var err error
+		withEnv(env, func() {
+			out, err = callTestTimeout(test, path, append([]byte(nil), input...), opt)
+		})
+
+		if errorText(err) != errorText(testErr) {
+			t.Errorf("Test function is not deterministic: call %d returned error %q, while call 1 returned %q",
+				i, errorText(err), errorText(testErr))
+			return false
+		}
+		if out := transformedOrRaw(path, out, opt); !bytes.Equal(out, first) {
+			t.Errorf("Test function is not deterministic: output of call %d differs from call 1.%s",
+				i, describeDifference(first, out, i, opt))
+			return false
+		}
+	}
+	return true
+}
+
+// transformedOrRaw is an internal function that returns the output
+// with the output transformations applied, or the raw output
+// if the transformations fail
+func transformedOrRaw(path string, output []byte, opt *optionSet) []byte {
+	if transformed, err := transformOutput(path, output, opt); err == nil {
+		return transformed
+	}
+	return output
+}
+
+// errorText is an internal function that returns the error message,
+// or an empty string if there is no error
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// describeDifference is an internal function that renders the diff
+// between the outputs of the first and the n-th call, if possible
+func describeDifference(first, output []byte, n int, opt *optionSet) string {
+	if opt.serializeFunc == nil {
+		return ""
+	}
+	a, err := opt.serializeFunc(first)
+	if err != nil {
+		return ""
+	}
+	b, err := opt.serializeFunc(output)
+	if err != nil {
+		return ""
+	}
+
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(a),
+		B:        difflib.SplitLines(b),
+		FromFile: "call 1",
+		ToFile:   fmt.Sprintf("call %d", n),
+		Context:  3,
+		Colored:  true,
+	})
+	if err != nil {
+		return ""
+	}
+	return " Here's the diff:\n\n" + text
+}
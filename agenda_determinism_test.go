@@ -0,0 +1,45 @@
+package agenda
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestCheckDeterminism runs agenda tests with the determinism check
+func TestCheckDeterminism(t *testing.T) {
+	Run(t, "testdata/01/default", test01, CheckDeterminism(3))
+}
+
+// TestNondeterministic is a traditional (non agenda-based) test
+// that checks that differing outputs and errors are reported
+func TestNondeterministic(t *testing.T) {
+	opt := newOptionSet([]option{CheckDeterminism(2)})
+	calls := 0
+	test := func(path string, data []byte) ([]byte, error) {
+		calls++
+		return []byte(fmt.Sprintf("call %d\n", calls)), nil
+	}
+
+	var r fakeReporter
+	output, err := test("1.json", nil)
+	if checkDeterminism(&r, test, "1.json", nil, nil, output, err, opt) {
+		t.Error("Expected the check to fail")
+	}
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "+call 2") {
+		t.Errorf("Expected the diff to be reported, got %v", r.errors)
+	}
+
+	failing := func(path string, data []byte) ([]byte, error) {
+		calls++
+		return nil, fmt.Errorf("failure %d", calls)
+	}
+	r = fakeReporter{}
+	output, err = failing("1.json", nil)
+	if checkDeterminism(&r, failing, "1.json", nil, nil, output, err, opt) {
+		t.Error("Expected the check to fail")
+	}
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "returned error") {
+		t.Errorf("Expected the error mismatch to be reported, got %v", r.errors)
+	}
+}
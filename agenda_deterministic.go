@@ -0,0 +1,175 @@
+package agenda
+
+import (
+	"bytes"
+	"hash/fnv"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Epoch is the time the clocks returned by Deterministic() start at
+var Epoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Clock is a fake clock for test functions whose output depends
+// on the current time. It is safe for concurrent use.
+type Clock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewClock returns the clock which starts at `start`
+// and advances by `step` after each Now() call
+func NewClock(start time.Time, step time.Duration) *Clock {
+	return &Clock{now: start, step: step}
+}
+
+// Now returns the current time of the clock
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// Advance moves the clock forward by `d`
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Deterministic returns the fixed clock (which starts at Epoch and doesn't
+// advance on its own) and the source of random numbers seeded with the hash
+// of the test name, so that the test functions producing time- or
+// randomness-dependent output can be made snapshot-stable. Call it
+// for each case to get the same values regardless of the order of cases.
+// Note that the returned source is not safe for concurrent use.
+//
+// Example:
+//
+//		agenda.Run(t, "./testdata/mytest", func(path string, data []byte) ([]byte, error) {
+//			clock, src := agenda.Deterministic(t)
+//			svc := NewService(clock.Now, rand.New(src))
+//			// ...
+//		})
+func Deterministic(t *testing.T) (*Clock, rand.Source) {
+	h := fnv.New64a()
+	h.Write([]byte(t.Name()))
+	return NewClock(Epoch, 0), rand.NewSource(int64(h.Sum64()))
+}
+
+// timestampRe matches RFC 3339 timestamps, optionally with a space
+// instead of 'T', fractional seconds and a time zone offset
+var timestampRe = regexp.MustCompile(
+	`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+
+// timestampPlaceholder replaces the scrubbed timestamps
+const timestampPlaceholder = "<timestamp>"
+
+// ScrubTimestamps replaces all RFC 3339-like timestamps in the generated
+// output with the "<timestamp>" placeholder before it is saved or compared,
+// for the timestamps that can't be controlled with a fake clock.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.ScrubTimestamps())
+func ScrubTimestamps() option {
+	return func(o *optionSet) {
+		o.outputTransforms = append(o.outputTransforms, func(path string, output []byte) ([]byte, error) {
+			return timestampRe.ReplaceAll(output, []byte(timestampPlaceholder)), nil
+		})
+	}
+}
+
+// anyTimestampRe matches RFC 3339-like timestamps (see timestampRe)
+// and Unix epoch timestamps in seconds or milliseconds
+var anyTimestampRe = regexp.MustCompile(timestampRe.String() + `|\b\d{10}(\d{3})?\b`)
+
+// TimestampTolerance makes the timestamps in the generated output compare
+// equal to the timestamps in the reference data if they are within `delta`
+// of each other, for the outputs that embed real clock readings which can't
+// be frozen or scrubbed. RFC 3339-like timestamps and Unix epoch timestamps
+// (10-digit seconds or 13-digit milliseconds) are recognized, and matched
+// with the ones in the reference data in the order of their appearance.
+// Timestamps that differ more are still reported. Result files are saved as is.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.TimestampTolerance(5*time.Second))
+func TimestampTolerance(delta time.Duration) option {
+	return func(o *optionSet) {
+		o.normalizers = append(o.normalizers, func(reference, output []byte) ([]byte, []byte) {
+			return reference, alignTimestamps(reference, output, delta)
+		})
+	}
+}
+
+// alignTimestamps is an internal function that replaces the timestamps
+// in the output with the corresponding timestamps of the reference data
+// if they are within `delta` of each other
+func alignTimestamps(reference, output []byte, delta time.Duration) []byte {
+	refLocs := anyTimestampRe.FindAllIndex(reference, -1)
+	outLocs := anyTimestampRe.FindAllIndex(output, -1)
+
+	var buf bytes.Buffer
+	last := 0
+	for i := 0; i < len(refLocs) && i < len(outLocs); i++ {
+		ref := reference[refLocs[i][0]:refLocs[i][1]]
+		out := output[outLocs[i][0]:outLocs[i][1]]
+
+		refTime, ok := parseTimestamp(string(ref))
+		if !ok {
+			continue
+		}
+		outTime, ok := parseTimestamp(string(out))
+		if !ok {
+			continue
+		}
+		if d := outTime.Sub(refTime); d > delta || d < -delta {
+			continue
+		}
+
+		buf.Write(output[last:outLocs[i][0]])
+		buf.Write(ref)
+		last = outLocs[i][1]
+	}
+	if last == 0 {
+		return output
+	}
+	buf.Write(output[last:])
+	return buf.Bytes()
+}
+
+// timestampLayouts are the layouts of RFC 3339-like timestamps
+// (fractional seconds are accepted by time.Parse implicitly)
+var timestampLayouts = []string{
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+}
+
+// parseTimestamp is an internal function that parses
+// the timestamp matched by anyTimestampRe
+func parseTimestamp(s string) (time.Time, bool) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if len(s) == 13 {
+			return time.Unix(0, n*int64(time.Millisecond)), true
+		}
+		return time.Unix(n, 0), true
+	}
+
+	s = strings.Replace(s, " ", "T", 1)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,64 @@
+package agenda
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// TestDeterministic is a traditional (non agenda-based) test
+// that checks that the clock and the random source are stable
+func TestDeterministic(t *testing.T) {
+	clock1, src1 := Deterministic(t)
+	clock2, src2 := Deterministic(t)
+
+	if !clock1.Now().Equal(Epoch) || !clock1.Now().Equal(Epoch) {
+		t.Errorf("Expected the clock to be fixed at %v", Epoch)
+	}
+	clock1.Advance(time.Hour)
+	if !clock1.Now().Equal(Epoch.Add(time.Hour)) || !clock2.Now().Equal(Epoch) {
+		t.Errorf("Expected only the first clock to advance")
+	}
+
+	if src1.Int63() != src2.Int63() {
+		t.Errorf("Expected the random sources to produce the same values")
+	}
+
+	clock := NewClock(Epoch, time.Second)
+	clock.Now()
+	if !clock.Now().Equal(Epoch.Add(time.Second)) {
+		t.Errorf("Expected the clock to advance by a second")
+	}
+}
+
+// TestScrubTimestamps runs agenda tests whose output contains
+// the current time and random numbers
+func TestScrubTimestamps(t *testing.T) {
+	Run(t, "testdata/scrub-timestamps", func(path string, data []byte) ([]byte, error) {
+		_, src := Deterministic(t)
+		r := rand.New(src)
+		return []byte(fmt.Sprintf("generated at %s (%s), id %d\n",
+			time.Now().Format(time.RFC3339Nano), time.Now().Format("2006-01-02 15:04:05"), r.Intn(1000))), nil
+	}, ScrubTimestamps())
+}
+
+// TestTimestampTolerance is a traditional (non agenda-based) test that
+// compares outputs with slightly different timestamps
+func TestTimestampTolerance(t *testing.T) {
+	opt := newOptionSet([]option{TimestampTolerance(5 * time.Second)})
+	reference := []byte("started 2020-01-02T03:04:05Z, epoch 1577934245, ms 1577934245000\n")
+
+	var r fakeReporter
+	output := []byte("started 2020-01-02T03:04:08.5Z, epoch 1577934243, ms 1577934249999\n")
+	compareOutput(&r, "out.txt.result", reference, output, opt)
+	if len(r.errors) != 0 {
+		t.Errorf("Expected no differences, got %v", r.errors)
+	}
+
+	output = []byte("started 2020-01-02 03:04:05+00:00, epoch 1577934345, ms 1577934245000\n")
+	compareOutput(&r, "out.txt.result", reference, output, opt)
+	if len(r.errors) != 1 {
+		t.Errorf("Expected 1 error, got %v", r.errors)
+	}
+}
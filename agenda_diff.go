@@ -0,0 +1,334 @@
+package agenda
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// StructDiff is a shortcut option that sets the comparator function
+// which decodes both the reference data and the generated output (JSON)
+// into new values of the same type as `v`, and compares them field by field.
+// Each mismatch is reported on its own line with the Go field path,
+// the type and both values:
+//
+//     Out.Items[3].Price (float64): got 10.5, want 10
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.StructDiff(Out{}))
+func StructDiff(v interface{}) option {
+	typ := decodedType(v, "StructDiff")
+
+	return Comparator(func(reference, output []byte) (string, error) {
+		return decodePair(typ, reference, output, func(want, got reflect.Value) string {
+			var lines []string
+			diffValues(typ.Name(), want.Elem(), got.Elem(), &lines)
+			return strings.Join(lines, "\n")
+		})
+	})
+}
+
+// CompareDecoded is similar to StructDiff, but the decoded values
+// (pointers to new values of the same type as `v`) are compared with
+// the `diff` function, which returns an empty string if the values are
+// equal, or the report of the differences otherwise. This allows using
+// third-party comparison libraries such as go-cmp with their options
+// (e.g. to compare floating-point numbers approximately).
+//
+// Example:
+//
+//		agenda.Run(t, "./testdata/mytest", testFunc, agenda.CompareDecoded(Out{}, func(want, got interface{}) string {
+//			return cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-9))
+//		}))
+func CompareDecoded(v interface{}, diff func(want, got interface{}) string) option {
+	typ := decodedType(v, "CompareDecoded")
+	if diff == nil {
+		panic("diff function is nil")
+	}
+
+	return Comparator(func(reference, output []byte) (string, error) {
+		return decodePair(typ, reference, output, func(want, got reflect.Value) string {
+			return diff(want.Interface(), got.Interface())
+		})
+	})
+}
+
+// decodedType is an internal function that returns the type
+// the data is decoded into for the value passed to the option
+func decodedType(v interface{}, option string) reflect.Type {
+	typ := reflect.TypeOf(v)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil {
+		panic(option + " value is nil")
+	}
+	return typ
+}
+
+// decodePair is an internal function that decodes both the reference
+// data and the generated output (JSON) into new values of the type
+// and compares the pointers to them with the `diff` function,
+// unless the data is the same
+func decodePair(typ reflect.Type, reference, output []byte, diff func(want, got reflect.Value) string) (string, error) {
+	if bytes.Equal(reference, output) {
+		return "", nil
+	}
+
+	want := reflect.New(typ)
+	if err := json.Unmarshal(reference, want.Interface()); err != nil {
+		return "", fmt.Errorf("can't decode reference data: %v", err)
+	}
+
+	got := reflect.New(typ)
+	if err := json.Unmarshal(output, got.Interface()); err != nil {
+		return "", fmt.Errorf("can't decode generated output: %v", err)
+	}
+
+	return diff(want, got), nil
+}
+
+// diffValues is an internal function that recursively compares
+// two values of the same type and appends a line for each mismatch
+func diffValues(path string, want, got reflect.Value, lines *[]string) {
+	switch want.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if want.IsNil() || got.IsNil() {
+			if want.IsNil() != got.IsNil() {
+				*lines = append(*lines, mismatch(path, want, got))
+			}
+			return
+		}
+		if want.Kind() == reflect.Interface && want.Elem().Type() != got.Elem().Type() {
+			*lines = append(*lines, mismatch(path, want, got))
+			return
+		}
+		diffValues(path, want.Elem(), got.Elem(), lines)
+
+	case reflect.Struct:
+		typ := want.Type()
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if f.PkgPath != "" {
+				continue // unexported field
+			}
+			diffValues(joinPath(path, f.Name), want.Field(i), got.Field(i), lines)
+		}
+
+	case reflect.Slice, reflect.Array:
+		n := want.Len()
+		if got.Len() < n {
+			n = got.Len()
+		}
+		for i := 0; i < n; i++ {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), want.Index(i), got.Index(i), lines)
+		}
+		for i := n; i < want.Len(); i++ {
+			*lines = append(*lines, fmt.Sprintf("%s[%d]: missing, want %s", path, i, formatValue(want.Index(i))))
+		}
+		for i := n; i < got.Len(); i++ {
+			*lines = append(*lines, fmt.Sprintf("%s[%d]: unexpected, got %s", path, i, formatValue(got.Index(i))))
+		}
+
+	case reflect.Map:
+		keys := make(map[string]reflect.Value)
+		for _, k := range want.MapKeys() {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+		for _, k := range got.MapKeys() {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			k := keys[name]
+			keyPath := fmt.Sprintf("%s[%q]", path, name)
+			w, g := want.MapIndex(k), got.MapIndex(k)
+			switch {
+			case !g.IsValid():
+				*lines = append(*lines, fmt.Sprintf("%s: missing, want %s", keyPath, formatValue(w)))
+			case !w.IsValid():
+				*lines = append(*lines, fmt.Sprintf("%s: unexpected, got %s", keyPath, formatValue(g)))
+			default:
+				diffValues(keyPath, w, g, lines)
+			}
+		}
+
+	default:
+		if !reflect.DeepEqual(want.Interface(), got.Interface()) {
+			*lines = append(*lines, mismatch(path, want, got))
+		}
+	}
+}
+
+func mismatch(path string, want, got reflect.Value) string {
+	return fmt.Sprintf("%s (%s): got %s, want %s",
+		path, want.Type(), formatValue(got), formatValue(want))
+}
+
+func formatValue(v reflect.Value) string {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return "nil"
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String {
+		return fmt.Sprintf("%q", v.Interface())
+	}
+	return fmt.Sprintf("%+v", v.Interface())
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// JSONDiff is a shortcut option that sets the comparator function
+// which decodes both the reference data and the generated output as
+// arbitrary JSON documents, and reports each changed value on its own
+// line with its path, for deeply nested documents where line diffs
+// are hard to read:
+//
+//     items[3].price: 10 -> 12
+//     missing key meta.version (want "1.2")
+//     unexpected key meta.debug (got true)
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.JSONDiff())
+func JSONDiff() option {
+	return Comparator(func(reference, output []byte) (string, error) {
+		if bytes.Equal(reference, output) {
+			return "", nil
+		}
+
+		want, err := decodeJSON(reference)
+		if err != nil {
+			return "", fmt.Errorf("can't decode reference data: %v", err)
+		}
+
+		got, err := decodeJSON(output)
+		if err != nil {
+			return "", fmt.Errorf("can't decode generated output: %v", err)
+		}
+
+		var lines []string
+		diffJSON("", want, got, &lines)
+		return strings.Join(lines, "\n"), nil
+	})
+}
+
+// decodeJSON decodes the JSON document, keeping numbers as is
+func decodeJSON(data []byte) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// diffJSON is an internal function that recursively compares
+// two decoded JSON values and appends a line for each change
+func diffJSON(path string, want, got interface{}, lines *[]string) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			keyPath := jsonKeyPath(path, k)
+			wv, inWant := w[k]
+			gv, inGot := g[k]
+			switch {
+			case !inGot:
+				*lines = append(*lines, fmt.Sprintf("missing key %s (want %s)", keyPath, formatJSON(wv)))
+			case !inWant:
+				*lines = append(*lines, fmt.Sprintf("unexpected key %s (got %s)", keyPath, formatJSON(gv)))
+			default:
+				diffJSON(keyPath, wv, gv, lines)
+			}
+		}
+		return
+
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+
+		n := len(w)
+		if len(g) < n {
+			n = len(g)
+		}
+		for i := 0; i < n; i++ {
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], lines)
+		}
+		for i := n; i < len(w); i++ {
+			*lines = append(*lines, fmt.Sprintf("missing element %s[%d] (want %s)", path, i, formatJSON(w[i])))
+		}
+		for i := n; i < len(g); i++ {
+			*lines = append(*lines, fmt.Sprintf("unexpected element %s[%d] (got %s)", path, i, formatJSON(g[i])))
+		}
+		return
+
+	default:
+		if reflect.DeepEqual(want, got) {
+			return
+		}
+	}
+
+	if path == "" {
+		path = "(root)"
+	}
+	*lines = append(*lines, fmt.Sprintf("%s: %s -> %s", path, formatJSON(want), formatJSON(got)))
+}
+
+// jsonKeyPath returns the path of the object key, using the bracket
+// notation for keys which are not valid identifiers
+func jsonKeyPath(path, key string) string {
+	identifier := key != ""
+	for i, r := range key {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			identifier = false
+			break
+		}
+	}
+	if !identifier {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	return joinPath(path, key)
+}
+
+func formatJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
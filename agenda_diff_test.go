@@ -0,0 +1,99 @@
+package agenda
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+)
+
+type structDiffItem struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+type structDiffOut struct {
+	Total  float64            `json:"total"`
+	Items  []structDiffItem   `json:"items"`
+	Meta   map[string]string  `json:"meta"`
+	Parent *structDiffItem    `json:"parent"`
+	Extra  interface{}        `json:"extra"`
+	Counts map[string]float64 `json:"counts"`
+}
+
+// TestStructDiff runs agenda tests to verify the reports
+// rendered by the StructDiff comparator; each input file contains
+// the reference and the generated output to compare
+func TestStructDiff(t *testing.T) {
+	compare := &optionSet{}
+	StructDiff(structDiffOut{})(compare)
+
+	Run(t, "testdata/struct-diff", func(path string, data []byte) ([]byte, error) {
+		in := struct {
+			Reference json.RawMessage `json:"reference"`
+			Output    json.RawMessage `json:"output"`
+		}{}
+
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, err
+		}
+
+		report, err := compare.compareFunc(in.Reference, in.Output)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(report), nil
+	})
+}
+
+// TestJSONDiff runs agenda tests to verify the reports
+// rendered by the JSONDiff comparator; each input file contains
+// the reference and the generated output to compare
+func TestJSONDiff(t *testing.T) {
+	compare := &optionSet{}
+	JSONDiff()(compare)
+
+	Run(t, "testdata/json-diff", func(path string, data []byte) ([]byte, error) {
+		in := struct {
+			Reference json.RawMessage `json:"reference"`
+			Output    json.RawMessage `json:"output"`
+		}{}
+
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, err
+		}
+
+		report, err := compare.compareFunc(in.Reference, in.Output)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(report), nil
+	})
+}
+
+// TestCompareDecoded is a traditional (non agenda-based) test
+// that compares the decoded values with a custom diff function
+func TestCompareDecoded(t *testing.T) {
+	compare := &optionSet{}
+	CompareDecoded(&structDiffItem{}, func(want, got interface{}) string {
+		w, g := want.(*structDiffItem), got.(*structDiffItem)
+		if w.Name != g.Name || math.Abs(w.Price-g.Price) > 0.01 {
+			return fmt.Sprintf("want %+v, got %+v", *w, *g)
+		}
+		return ""
+	})(compare)
+
+	report, err := compare.compareFunc([]byte(`{"name":"a","price":10}`), []byte(`{"price":10.001,"name":"a"}`))
+	if err != nil || report != "" {
+		t.Errorf("Expected no differences, got %q (%v)", report, err)
+	}
+
+	report, err = compare.compareFunc([]byte(`{"name":"a","price":10}`), []byte(`{"name":"a","price":11}`))
+	if err != nil || report != "want {Name:a Price:10}, got {Name:a Price:11}" {
+		t.Errorf("Unexpected report %q (%v)", report, err)
+	}
+
+	if _, err := compare.compareFunc([]byte(`{}`), []byte(`[`)); err == nil {
+		t.Error("Expected a decoding error")
+	}
+}
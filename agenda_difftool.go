@@ -0,0 +1,71 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// diffToolEnv is the name of the environment variable which sets
+// the external diff tool unless the DiffTool() option is used
+const diffToolEnv = "AGENDA_DIFFTOOL"
+
+// DiffTool allows you to specify the external diff tool (e.g. "meld")
+// which is launched on mismatch with the paths of two temporary files
+// holding the reference data and the generated output, for those who prefer
+// GUI diffing of large structured snapshots. The command can include
+// arguments ("code --wait --diff"); the test waits for it to exit.
+// The tool can also be set with the AGENDA_DIFFTOOL environment variable.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.DiffTool("meld"))
+func DiffTool(command string) option {
+	return func(o *optionSet) {
+		o.diffTool = command
+	}
+}
+
+// diffTool is an internal function that returns the configured diff tool
+func diffTool(opt *optionSet) string {
+	if opt.diffTool != "" {
+		return opt.diffTool
+	}
+	return os.Getenv(diffToolEnv)
+}
+
+// runDiffTool is an internal function that saves the reference data and
+// the generated output into temporary files and launches the diff tool
+func runDiffTool(t reporter, tool, resultPath string, referenceOutput, output []byte, opt *optionSet) {
+	dir, err := ioutil.TempDir("", "agenda-difftool")
+	if err != nil {
+		t.Errorf("Can't create the directory for the diff tool: %v", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Base(resultPath)
+	refPath := filepath.Join(dir, "reference-"+name)
+	outPath := filepath.Join(dir, "generated-"+name)
+	if err := ioutil.WriteFile(refPath, referenceOutput, 0644); err != nil {
+		t.Errorf("Can't save the reference data for the diff tool: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(outPath, output, 0644); err != nil {
+		t.Errorf("Can't save the generated output for the diff tool: %v", err)
+		return
+	}
+
+	args := strings.Fields(tool)
+	logf(t, opt, logInfo, "Launching the diff tool '%s'", tool)
+	cmd := exec.Command(args[0], append(args[1:], refPath, outPath)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		// diff tools commonly exit with a non-zero code when files differ
+		if _, ok := err.(*exec.ExitError); !ok {
+			t.Errorf("Can't run the diff tool: %v", err)
+		}
+	}
+}
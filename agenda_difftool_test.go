@@ -0,0 +1,40 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDiffTool is a traditional (non agenda-based) test that checks
+// that the diff tool is launched with the reference and generated data
+func TestDiffTool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	outPath := filepath.Join(dir, "out")
+	script := filepath.Join(dir, "difftool.sh")
+	err = ioutil.WriteFile(script, []byte("#!/bin/sh\ncat \"$2\" \"$3\" > \""+outPath+"\"\nexit 1\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &fakeReporter{}
+	compareOutput(r, "1.json.result", []byte("want\n"), []byte("got\n"),
+		newOptionSet([]option{DiffTool(script + " --flag")}))
+	if len(r.errors) != 1 {
+		t.Errorf("Expected only the mismatch to be reported, got %v", r.errors)
+	}
+
+	data, err := ioutil.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "want\ngot\n" {
+		t.Errorf("Unexpected data passed to the diff tool: %q", data)
+	}
+}
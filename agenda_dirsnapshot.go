@@ -0,0 +1,126 @@
+package agenda
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// DirEntry describes a file in the directory snapshot
+type DirEntry struct {
+	Path          string `json:"path"`
+	Size          *int64 `json:"size,omitempty"`
+	Mode          string `json:"mode,omitempty"`
+	SHA256        string `json:"sha256,omitempty"`
+	Content       string `json:"content,omitempty"`
+	ContentBase64 string `json:"contentBase64,omitempty"` // for content which is not valid UTF-8
+}
+
+// dirSnapshotConfig is an internal structure that describes
+// which file attributes are included in the directory snapshot
+type dirSnapshotConfig struct {
+	noSize  bool
+	mode    bool
+	hash    bool
+	content bool
+}
+
+// DirSnapshotOption defines the option of DirSnapshot()
+type DirSnapshotOption func(c *dirSnapshotConfig)
+
+// ExcludeSize excludes file sizes from the directory snapshot
+func ExcludeSize() DirSnapshotOption {
+	return func(c *dirSnapshotConfig) {
+		c.noSize = true
+	}
+}
+
+// IncludeMode includes file modes (e.g. "-rw-r--r--")
+// in the directory snapshot
+func IncludeMode() DirSnapshotOption {
+	return func(c *dirSnapshotConfig) {
+		c.mode = true
+	}
+}
+
+// IncludeHash includes SHA-256 checksums of the files
+// in the directory snapshot
+func IncludeHash() DirSnapshotOption {
+	return func(c *dirSnapshotConfig) {
+		c.hash = true
+	}
+}
+
+// IncludeContent includes the contents of the files
+// in the directory snapshot
+func IncludeContent() DirSnapshotOption {
+	return func(c *dirSnapshotConfig) {
+		c.content = true
+	}
+}
+
+// DirSnapshot walks the directory and renders the list of its files
+// (with their paths and sizes by default) as indented JSON, so that
+// generated directory layouts can be snapshotted easily.
+//
+// Example:
+//
+//		agenda.Run(t, "./testdata/mytest", func(path string, data []byte) ([]byte, error) {
+//			dir := generateSite(data)
+//			return agenda.DirSnapshot(dir, agenda.IncludeHash())
+//		})
+func DirSnapshot(path string, options ...DirSnapshotOption) ([]byte, error) {
+	c := &dirSnapshotConfig{}
+	for _, f := range options {
+		f(c)
+	}
+
+	out := make([]*DirEntry, 0)
+	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		e := &DirEntry{Path: path}
+		if !c.noSize {
+			size := info.Size()
+			e.Size = &size
+		}
+		if c.mode {
+			e.Mode = info.Mode().String()
+		}
+		if c.hash || c.content {
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if c.hash {
+				sum := sha256.Sum256(data)
+				e.SHA256 = hex.EncodeToString(sum[:])
+			}
+			if c.content {
+				if utf8.Valid(data) {
+					e.Content = string(data)
+				} else {
+					e.ContentBase64 = base64.StdEncoding.EncodeToString(data)
+				}
+			}
+		}
+		out = append(out, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Pretty-print the JSON for easier diff-ing
+	return json.MarshalIndent(out, "", "\t")
+}
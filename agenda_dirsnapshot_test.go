@@ -0,0 +1,22 @@
+package agenda
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestDirSnapshotOptions runs agenda tests to verify
+// the directory snapshots with optional file attributes
+func TestDirSnapshotOptions(t *testing.T) {
+	Run(t, "testdata/dir-snapshot-options", func(path string, data []byte) ([]byte, error) {
+		in := struct {
+			Path string `json:"path"`
+		}{}
+
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, err
+		}
+
+		return DirSnapshot(in.Path, ExcludeSize(), IncludeHash(), IncludeContent())
+	})
+}
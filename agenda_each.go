@@ -0,0 +1,180 @@
+package agenda
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// RunEach executes an agenda test function against each element of the JSON
+// arrays stored in the input data files in the directory, for small parametric
+// cases which don't warrant one file each. Each element becomes a separate
+// subtest, and the test function is called with the element as raw JSON data.
+// The result file holds the JSON array of the generated outputs, and each output
+// is compared with the element of this array at the same position. Outputs which
+// are valid JSON are stored as is, other outputs are stored as JSON strings.
+// Options tracking the outcome of whole cases (e.g. Observe(), History()
+// or Cache()) are not supported, as the elements are run as subtests.
+//
+// Example:
+//
+//		// 01.json: [{"a":1,"b":2}, {"a":3,"b":4}]
+//		// 01.json.result: [{"result":3}, {"result":7}]
+//		agenda.RunEach(t, "./testdata/mytest", testFunc)
+func RunEach(t *testing.T, dir string, test Test, options ...option) {
+	if test == nil {
+		panic("test function is nil")
+	}
+
+	runElements(t, "RunEach", dir, test, newOptionSet(options), jsonArrayFormat{})
+}
+
+// elementFormat describes how the input data files are split into elements,
+// and how the generated outputs are stored in the result file
+type elementFormat interface {
+	// split returns the elements of the input data file along with their names
+	split(data []byte) (names []string, elements []json.RawMessage, err error)
+	// parse returns the elements of the result file
+	parse(data []byte) ([]json.RawMessage, error)
+	// join renders the result file
+	join(elements []json.RawMessage) ([]byte, error)
+}
+
+// jsonArrayFormat stores the elements as JSON arrays (see RunEach())
+type jsonArrayFormat struct{}
+
+func (jsonArrayFormat) split(data []byte) ([]string, []json.RawMessage, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, nil, fmt.Errorf("not a JSON array: %v", err)
+	}
+	names := make([]string, len(elements))
+	for i := range elements {
+		names[i] = strconv.Itoa(i)
+	}
+	return names, elements, nil
+}
+
+func (jsonArrayFormat) parse(data []byte) ([]json.RawMessage, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, fmt.Errorf("not a JSON array: %v", err)
+	}
+	return elements, nil
+}
+
+func (jsonArrayFormat) join(elements []json.RawMessage) ([]byte, error) {
+	data, err := json.MarshalIndent(elements, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// runElements is an internal function that runs the test against each element
+// of the input data files, storing the outputs in the format `f`
+func runElements(t *testing.T, runner, dir string, test Test, opt *optionSet, f elementFormat) {
+	rejectRunOptions(t, runner, opt)
+	opt.roots = append(opt.roots, dir)
+
+	cases := selectCases(t, findCases(t, dir, opt), opt)
+
+	runNested(t, cases, 0, func(t *testing.T, c *testCase) {
+		logf(t, opt, logInfo, "%s", c.path)
+		input, err := c.readInput(opt)
+		if err != nil {
+			t.Errorf("Can't read the file: %v", err)
+			return
+		}
+
+		names, elements, err := f.split(input)
+		if err != nil {
+			t.Errorf("Can't parse the file: %v", err)
+			return
+		}
+
+		var reference []json.RawMessage
+		if !opt.initMode {
+			registerDir(filepath.Dir(c.resultPath))
+			data, err := readFile(c.resultPath, opt)
+			if os.IsNotExist(err) {
+				t.Errorf("File '%s' doesn't exist (try initializing snapshots with 'go test -args init')", c.resultPath)
+				return
+			}
+			if err != nil {
+				t.Errorf("Can't read the '%s' file: %v", c.resultPath, err)
+				return
+			}
+			if reference, err = f.parse(data); err != nil {
+				t.Errorf("Can't parse the '%s' file: %v", c.resultPath, err)
+				return
+			}
+			if len(reference) != len(elements) {
+				t.Errorf("File '%s' has %d elements, but the input has %d", c.resultPath, len(reference), len(elements))
+			}
+		}
+
+		outputs := make([]json.RawMessage, len(elements))
+		passed := true
+		for i := range elements {
+			i := i
+			passed = t.Run(names[i], func(t *testing.T) {
+				output, err := callTestTimeout(test, c.path, elements[i], opt)
+				if err != nil {
+					t.Errorf("Error during test() call: %v", describeError(err))
+					return
+				}
+				output, err = transformOutput(c.path, output, opt)
+				if err != nil {
+					t.Errorf("Can't transform the output: %v", err)
+					return
+				}
+				outputs[i] = eachElement(output)
+
+				if opt.initMode || i >= len(reference) {
+					return
+				}
+				compareOutput(t, fmt.Sprintf("%s[%s]", c.resultPath, names[i]),
+					indentElement(reference[i]), indentElement(outputs[i]), opt)
+			}) && passed
+		}
+
+		if opt.initMode {
+			if !passed {
+				t.Errorf("Not writing the '%s' file, as some of the elements failed", c.resultPath)
+				return
+			}
+			data, err := f.join(outputs)
+			if err != nil {
+				t.Errorf("Can't serialize the outputs: %v", err)
+				return
+			}
+			checkResult(t, c.resultPath, data, opt)
+		}
+	})
+}
+
+// eachElement is an internal function that returns the output
+// as an element of the JSON array stored in the result file
+func eachElement(output []byte) json.RawMessage {
+	if json.Valid(output) {
+		return json.RawMessage(bytes.TrimSpace(output))
+	}
+	data, _ := json.Marshal(string(output))
+	return data
+}
+
+// indentElement is an internal function that renders the array element
+// in a uniform way, so that it can be compared and diffed
+func indentElement(element json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, element, "", "\t"); err != nil {
+		return element
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
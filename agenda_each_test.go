@@ -0,0 +1,30 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// TestRunEach runs agenda tests for each element of the JSON arrays
+func TestRunEach(t *testing.T) {
+	RunEach(t, "testdata/each", test01)
+}
+
+// TestEachElement is a traditional (non agenda-based) test
+// that checks how outputs are stored in the result file
+func TestEachElement(t *testing.T) {
+	var tests = []struct {
+		output string
+		want   string
+	}{
+		{`{"a":1}`, `{"a":1}`},
+		{" [1, 2]\n", `[1, 2]`},
+		{"plain text\n", `"plain text\n"`},
+		{"", `""`},
+	}
+
+	for _, test := range tests {
+		if got := string(eachElement([]byte(test.output))); got != test.want {
+			t.Errorf("Expected %s, got %s", test.want, got)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package agenda
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// envSuffix is appended to the file path of the main test file
+// to get the file name of the case environment file
+const envSuffix = ".env"
+
+// envMu serializes the execution of test functions
+// that run with a modified environment
+var envMu sync.Mutex
+
+// CaseEnv enables setting case-specific environment variables around
+// the test function call, which allows data-driven testing of code paths
+// that depend on the environment (feature flags, locale, TZ) without separate
+// test functions. Variables are read from the file ending with ".env"
+// (e.g. "01.json.env") with one KEY=VALUE pair per line; empty lines and lines
+// starting with '#' are ignored. The previous environment is restored after
+// the call, and calls that modify the environment are serialized, so that
+// cases running in parallel don't clash.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.CaseEnv())
+func CaseEnv() option {
+	return func(o *optionSet) {
+		o.caseEnv = true
+	}
+}
+
+// caseEnv is an internal function that returns the environment
+// variables to set for the case
+func caseEnv(c *testCase, opt *optionSet) (map[string]string, error) {
+	env := make(map[string]string)
+	for k, v := range c.env {
+		env[k] = v
+	}
+
+	if opt.caseEnv && !c.provided {
+		data, err := readFile(c.path+envSuffix, opt)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		fileEnv, err := parseEnv(data)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileEnv {
+			env[k] = v
+		}
+	}
+	return env, nil
+}
+
+// parseEnv is an internal function that parses KEY=VALUE lines
+func parseEnv(data []byte) (map[string]string, error) {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("malformed environment line: %q", line)
+		}
+		env[parts[0]] = parts[1]
+	}
+	return env, scanner.Err()
+}
+
+// withEnv is an internal function that calls `f` with the environment
+// variables set, restoring their previous values afterwards
+func withEnv(env map[string]string, f func()) {
+	if len(env) == 0 {
+		f()
+		return
+	}
+
+	envMu.Lock()
+	defer envMu.Unlock()
+
+	for k, v := range env {
+		if old, ok := os.LookupEnv(k); ok {
+			defer os.Setenv(k, old)
+		} else {
+			defer os.Unsetenv(k)
+		}
+		os.Setenv(k, v)
+	}
+
+	f()
+}
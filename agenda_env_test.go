@@ -0,0 +1,34 @@
+package agenda
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// testGreeting is a sample test callback function
+// whose output depends on the environment
+func testGreeting(path string, data []byte) ([]byte, error) {
+	in := struct {
+		Name string `json:"name"`
+	}{}
+
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	greeting := os.Getenv("AGENDA_TEST_GREETING")
+	if greeting == "" {
+		greeting = "Hello"
+	}
+	return []byte(greeting + ", " + in.Name + os.Getenv("AGENDA_TEST_PUNCTUATION")), nil
+}
+
+// TestCaseEnv runs agenda tests with case-specific environment variables
+func TestCaseEnv(t *testing.T) {
+	Run(t, "testdata/case-env", testGreeting, CaseEnv())
+
+	if v, ok := os.LookupEnv("AGENDA_TEST_GREETING"); ok {
+		t.Errorf("Expected the environment to be restored, got AGENDA_TEST_GREETING=%s", v)
+	}
+}
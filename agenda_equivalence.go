@@ -0,0 +1,182 @@
+package agenda
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+// EquivalenceReport is the report produced by AssertNoBehaviorChange()
+// when the ReportFile() option is used. When a key is provided,
+// the report is signed with HMAC-SHA256.
+type EquivalenceReport struct {
+	Time      time.Time          `json:"time"`
+	Equal     bool               `json:"equal"`
+	Cases     []*EquivalenceCase `json:"cases"`
+	Signature string             `json:"signature,omitempty"`
+}
+
+// EquivalenceCase holds the SHA-256 digests of the input
+// and of the outputs of both implementations for a single case
+type EquivalenceCase struct {
+	Path      string `json:"path"`
+	Input     string `json:"input"`
+	OldOutput string `json:"oldOutput"`
+	NewOutput string `json:"newOutput"`
+	Equal     bool   `json:"equal"`
+}
+
+// ReportFile allows you to specify the path to the report file
+// written by AssertNoBehaviorChange(). If `key` is not empty,
+// the report is signed with HMAC-SHA256 using this key;
+// use VerifyReport() to check the signature.
+//
+// Example:
+//
+// agenda.AssertNoBehaviorChange(t, "./testdata/mytest", oldFunc, newFunc,
+//     agenda.ReportFile("refactor-report.json", []byte(os.Getenv("REPORT_KEY"))))
+func ReportFile(path string, key []byte) option {
+	return func(o *optionSet) {
+		o.reportPath = path
+		o.reportKey = key
+	}
+}
+
+// AssertNoBehaviorChange runs all input data files in the directory
+// through both the old and the new implementation of the test function,
+// and fails if their outputs (or returned errors) are not byte-identical.
+// No result files are read or written; this mode is meant to prove that
+// a large pure refactoring doesn't change the behavior of the code.
+//
+// Example:
+//
+// agenda.AssertNoBehaviorChange(t, "./testdata/mytest", oldFunc, newFunc)
+func AssertNoBehaviorChange(t *testing.T, dir string, oldTest, newTest Test, options ...option) {
+	if oldTest == nil || newTest == nil {
+		panic("test function is nil")
+	}
+
+	opt := newOptionSet(options)
+	opt.initMode = false
+
+	cases := selectCases(t, findCases(t, dir, opt), opt)
+
+	report := &EquivalenceReport{
+		Time:  time.Now().UTC(),
+		Equal: true,
+	}
+	var mu sync.Mutex
+
+	runNested(t, cases, 0, func(t *testing.T, c *testCase) {
+		logf(t, opt, logInfo, "%s", c.path)
+		input, err := c.readInput(opt)
+		if err != nil {
+			t.Errorf("Can't read the file: %v", err)
+			return
+		}
+
+		oldOutput := behaviorOf(oldTest, c.path, input)
+		newOutput := behaviorOf(newTest, c.path, input)
+
+		rc := &EquivalenceCase{
+			Path:      c.path,
+			Input:     digest(input),
+			OldOutput: digest(oldOutput),
+			NewOutput: digest(newOutput),
+			Equal:     bytes.Equal(oldOutput, newOutput),
+		}
+		mu.Lock()
+		report.Cases = append(report.Cases, rc)
+		report.Equal = report.Equal && rc.Equal
+		mu.Unlock()
+
+		if !rc.Equal {
+			compareOutput(t, c.path+" (old implementation)", oldOutput, newOutput, opt)
+		}
+	})
+
+	if opt.reportPath != "" {
+		if err := writeReport(report, opt.reportPath, opt.reportKey); err != nil {
+			t.Errorf("Can't write the report: %v", err)
+		}
+	}
+}
+
+// VerifyReport reads the report file written by AssertNoBehaviorChange()
+// and verifies its signature with the provided key
+func VerifyReport(path string, key []byte) (*EquivalenceReport, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EquivalenceReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, err
+	}
+
+	signature := report.Signature
+	expected, err := signReport(report, key)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, errors.New("report signature doesn't match")
+	}
+	return report, nil
+}
+
+// behaviorOf is an internal function that returns the output
+// of the test function, or the error message if it failed
+func behaviorOf(test Test, path string, input []byte) []byte {
+	output, err := callTest(test, path, input)
+	if err != nil {
+		return []byte("error: " + err.Error())
+	}
+	return output
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// signReport is an internal function that computes
+// the signature of the report with its signature field omitted
+func signReport(report *EquivalenceReport, key []byte) (string, error) {
+	unsigned := *report
+	unsigned.Signature = ""
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// writeReport is an internal function that signs the report
+// (if the key is provided) and writes it to the file
+func writeReport(report *EquivalenceReport, path string, key []byte) error {
+	if len(key) > 0 {
+		signature, err := signReport(report, key)
+		if err != nil {
+			return err
+		}
+		report.Signature = signature
+	}
+
+	data, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
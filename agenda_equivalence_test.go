@@ -0,0 +1,67 @@
+package agenda
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// test01Refactored is an alternative implementation of test01
+// which must produce identical outputs
+func test01Refactored(path string, data []byte) ([]byte, error) {
+	var in struct{ A, B, C float64 }
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	var out struct {
+		Sum         float64     `json:"sum"`
+		Mul         float64     `json:"mul"`
+		Div         float64     `json:"div"`
+		Error       interface{} `json:"error"`
+		Explanation string      `json:"explanation"`
+	}
+	out.Sum = in.A + in.B + in.C
+	out.Mul = in.A * in.B * in.C
+	switch {
+	case in.B == 0:
+		out.Error = "Can't divide: B is zero"
+	case in.C == 0:
+		out.Error = "Can't divide: C is zero"
+	default:
+		out.Div = in.A / in.B / in.C
+	}
+	out.Explanation = fmt.Sprintf("Input parameters were: [%v, %v, %v]", in.A, in.B, in.C)
+
+	return json.Marshal(out)
+}
+
+// TestAssertNoBehaviorChange is a traditional (non agenda-based) test
+// that compares two implementations and verifies the signed report
+func TestAssertNoBehaviorChange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "report.json")
+	key := []byte("secret")
+	AssertNoBehaviorChange(t, "testdata/01/default", test01, test01Refactored,
+		ReportFile(path, key))
+
+	report, err := VerifyReport(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Equal || len(report.Cases) != 4 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	if _, err := VerifyReport(path, []byte("other")); err == nil {
+		t.Error("Expected the signature verification to fail with a different key")
+	}
+}
@@ -0,0 +1,114 @@
+package agenda
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+)
+
+// errorSuffix is appended to the file path of the main test file
+// (instead of the result suffix) to get the file name of the error snapshot
+const errorSuffix = ".error"
+
+// panicError is returned by callTest() when the test function panics
+type panicError struct {
+	value interface{}
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// callTest is an internal function that calls the test function,
+// converting a panic into an error, so that a panicking case doesn't
+// crash the whole test binary
+func callTest(test Test, path string, data []byte) (output []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			output, err = nil, &panicError{r, debug.Stack()}
+		}
+	}()
+	return test(path, data)
+}
+
+// describeError returns the error message,
+// along with the stack trace for panics and timeouts
+func describeError(err error) string {
+	switch e := err.(type) {
+	case *panicError:
+		return fmt.Sprintf("%v\n\n%s", e, e.stack)
+	case *timeoutError:
+		return fmt.Sprintf("%v\n\n%s", e, e.stack)
+	}
+	return err.Error()
+}
+
+// ErrorSnapshots enables treating the error returned by the test function
+// as a snapshot artifact, for cases where the error is the desired behavior.
+// In initialization mode, the error message is saved to the file ending with
+// ".error" (e.g. "01.json.error") instead of the result file. In regular mode,
+// the returned error is compared with the contents of that file, and the test
+// fails if the error was expected but not returned, or vice versa.
+// Panics in the test function are treated as errors with the "panic: "
+// prefix, so cases whose expected behavior is panicking can be snapshotted too.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.ErrorSnapshots())
+func ErrorSnapshots() option {
+	return func(o *optionSet) {
+		o.errorSnapshots = true
+	}
+}
+
+// errorPath returns the path of the error snapshot of the case
+func (c *testCase) errorPath(opt *optionSet) string {
+	return strings.TrimSuffix(c.resultPath, opt.resultSuffix) + errorSuffix
+}
+
+// checkError is an internal function that deals with error snapshots
+// of the case. It returns true if the outcome of the case was fully
+// handled, and the result file shouldn't be checked.
+func checkError(t reporter, c *testCase, testErr error, opt *optionSet) bool {
+	errorPath := c.errorPath(opt)
+
+	if opt.initMode {
+		stale := errorPath
+		if testErr != nil {
+			stale = c.resultPath
+			checkResult(t, errorPath, mask([]byte(testErr.Error()), opt), opt)
+		}
+		if err := removeFile(stale, opt); err != nil {
+			t.Errorf("Can't remove the stale '%s' file: %v", stale, err)
+		}
+		return testErr != nil
+	}
+
+	expected, err := readFile(errorPath, opt)
+	if os.IsNotExist(err) {
+		if testErr != nil {
+			t.Errorf("Error during test() call: %v", describeError(testErr))
+			return true
+		}
+		return false
+	}
+	if err != nil {
+		t.Errorf("Can't read the '%s' file: %v", errorPath, err)
+		return true
+	}
+	if err := verifyReference(t, errorPath, expected, opt); err != nil {
+		t.Errorf("Can't use the '%s' file: %v", errorPath, err)
+		return true
+	}
+
+	if testErr == nil {
+		t.Errorf("Expected test() call to fail with '%s' (see '%s'), but it succeeded",
+			expected, errorPath)
+		return true
+	}
+
+	compareFile(t, errorPath, nil, expected, mask([]byte(testErr.Error()), opt), opt)
+	return true
+}
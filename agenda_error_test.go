@@ -0,0 +1,67 @@
+package agenda
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testDiv is a sample test callback function
+// which returns an error when division is not possible
+func testDiv(path string, data []byte) ([]byte, error) {
+	in := struct {
+		A float64 `json:"a"`
+		B float64 `json:"b"`
+	}{}
+
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+	if in.B == 0 {
+		return nil, errors.New("division by zero")
+	}
+
+	return json.Marshal(in.A / in.B)
+}
+
+// TestErrorSnapshots runs agenda tests where some cases
+// are expected to fail with an error
+func TestErrorSnapshots(t *testing.T) {
+	Run(t, "testdata/error-snapshots", testDiv, ErrorSnapshots())
+}
+
+// testPanic is a sample test callback function
+// which panics on unexpected input
+func testPanic(path string, data []byte) ([]byte, error) {
+	var in []int
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+	if len(in) != 2 {
+		panic("expected two numbers")
+	}
+	return json.Marshal(in[0] / in[1])
+}
+
+// TestPanicSnapshots runs agenda tests where some cases
+// are expected to panic
+func TestPanicSnapshots(t *testing.T) {
+	Run(t, "testdata/panic-snapshots", testPanic, ErrorSnapshots())
+}
+
+// TestCallTest is a traditional (non agenda-based) test
+// that verifies that panics are converted into errors with a stack trace
+func TestCallTest(t *testing.T) {
+	_, err := callTest(testPanic, "", []byte("[1]"))
+	pe, ok := err.(*panicError)
+	if !ok {
+		t.Fatalf("Expected a panic error, got %v", err)
+	}
+	if pe.Error() != "panic: expected two numbers" {
+		t.Errorf("Unexpected error message: %v", pe)
+	}
+	if !strings.Contains(describeError(err), "testPanic") {
+		t.Errorf("Expected the stack trace to mention testPanic, got %s", describeError(err))
+	}
+}
@@ -0,0 +1,49 @@
+package agenda
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// RunFile is similar to Run, but runs the test against exactly one input
+// data file, for one-off (e.g. large) cases that don't belong to a directory
+// of cases. The result file is located next to the input data file (or in
+// the ResultDir() directory), and the file suffix is not checked.
+//
+// Example:
+//
+// agenda.RunFile(t, "testdata/special/big-case.json", testFunc)
+func RunFile(t *testing.T, path string, test Test, options ...option) {
+	if test == nil {
+		panic("test function is nil")
+	}
+
+	opt := newOptionSet(options)
+	guardInit(t, opt)
+
+	dir := filepath.Dir(path)
+	registerDir(dir)
+	opt.roots = append(opt.roots, dir)
+	if opt.resultDir != "" {
+		opt.roots = append(opt.roots, opt.resultDir)
+	}
+
+	if opt.initMode {
+		logf(t, opt, logInfo, "Initializing snapshot for %s file", path)
+	} else {
+		logf(t, opt, logInfo, "Running snapshot-based test for %s file", path)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Can't read the input data file: %v", err)
+	}
+	c, err := newFileCase(dir, path, opt)
+	if err != nil {
+		t.Fatalf("Can't read the input data file: %v", err)
+	}
+
+	loadManifests(t, dir, opt)
+
+	runCases(t, selectCases(t, []*testCase{c}, opt), test, opt)
+}
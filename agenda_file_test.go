@@ -0,0 +1,11 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// TestRunFile runs agenda tests against individual files
+func TestRunFile(t *testing.T) {
+	RunFile(t, "testdata/01/default/1.json", test01)
+	RunFile(t, "testdata/01/custom-file-result-suffix/1.in", test01, ResultSuffix(".out"))
+}
@@ -0,0 +1,326 @@
+package agenda
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WritableFS is a minimal writable file system interface
+// that receives the result files generated in initialization mode.
+// File names are the same paths that would be used on disk
+// (relative to the directory you run the tests from, or absolute).
+// File systems which also implement `ReadFile(name string) ([]byte, error)`
+// don't get unchanged result files rewritten.
+type WritableFS interface {
+	MkdirAll(path string, perm os.FileMode) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+}
+
+// Output allows you to specify the file system where result files
+// are written to in initialization mode. By default, result files
+// are written directly next to the test data files. The files keeping
+// the state between runs (see History(), Cache() and Staleness())
+// and the Allure results are always written to disk, as they are read
+// back from there, and are not subject to ConfineWrites().
+//
+// Example:
+//
+// // generate snapshots into a staging directory for later review
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Output(agenda.DirFS("/tmp/staging")))
+func Output(fsys WritableFS) option {
+	return func(o *optionSet) {
+		o.fs = fsys
+	}
+}
+
+// ConfineWrites enables the enforcement mode which fails the test
+// whenever a result file is about to be written outside of the test
+// data directories (e.g. due to a path traversal in case names),
+// protecting the repository from runaway snapshot writers.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.ConfineWrites())
+func ConfineWrites() option {
+	return func(o *optionSet) {
+		o.confine = true
+	}
+}
+
+// FileMode allows you to specify the permissions of the result files
+// written in initialization mode (0644 by default). Existing files are
+// replaced, so read-only result files (e.g. 0444, to discourage editing
+// them by hand) can still be updated by re-initializing the snapshots.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.FileMode(0444))
+func FileMode(mode os.FileMode) option {
+	return func(o *optionSet) {
+		o.fileMode = mode
+	}
+}
+
+// DirMode allows you to specify the permissions of the directories
+// created in initialization mode (0755 by default)
+func DirMode(mode os.FileMode) option {
+	return func(o *optionSet) {
+		o.dirMode = mode
+	}
+}
+
+// writeFile is an internal function that writes the result file
+// into the configured file system, creating its directory if needed
+func writeFile(path string, data []byte, opt *optionSet) error {
+	if opt.confine {
+		if err := checkConfined(path, opt.roots); err != nil {
+			return err
+		}
+	}
+
+	return withRetry(opt, func() error {
+		if err := opt.fs.MkdirAll(filepath.Dir(path), opt.dirMode); err != nil {
+			return err
+		}
+		return opt.fs.WriteFile(path, data, opt.fileMode)
+	})
+}
+
+// FileWriter writes the data files of packages built on agenda
+// (e.g. the HTTP cassettes of the httprecord package) the same way
+// result files are written, following the Output(), ConfineWrites(),
+// FileMode(), DirMode() and InitMode() options of the run.
+type FileWriter struct {
+	opt *optionSet
+}
+
+// NewFileWriter returns the FileWriter configured by the options;
+// `dir` is the test data directory the writes are confined to
+// (see ConfineWrites()).
+//
+// Example:
+//
+// w := agenda.NewFileWriter("./testdata/mytest", agenda.Output(fsys))
+func NewFileWriter(dir string, options ...option) *FileWriter {
+	opt := newOptionSet(options)
+	opt.roots = append(opt.roots, dir)
+	return &FileWriter{opt: opt}
+}
+
+// InitMode returns true if initialization mode is enabled
+// (see InitMode())
+func (w *FileWriter) InitMode() bool {
+	return w.opt.initMode
+}
+
+// WriteFile writes the file, creating its directory if needed
+func (w *FileWriter) WriteFile(path string, data []byte) error {
+	return writeFile(path, data, w.opt)
+}
+
+// checkConfined is an internal function that returns an error
+// if the path is not located inside one of the root directories
+func checkConfined(path string, roots []string) error {
+	abs, err := resolvePath(path)
+	if err != nil {
+		return err
+	}
+
+	for _, root := range roots {
+		absRoot, err := resolvePath(root)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(absRoot, abs)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("writing '%s' outside of the test data directories is not allowed", path)
+}
+
+// resolvePath is an internal function that returns the absolute path
+// with symlinks resolved for the longest existing part of it
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var rest []string
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if dir == filepath.Dir(dir) {
+			return abs, nil
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+	}
+}
+
+// fileReader is an optional interface that a WritableFS can implement
+// to allow reading back the previously written files
+type fileReader interface {
+	ReadFile(name string) ([]byte, error)
+}
+
+// unchanged is an internal function that returns true if the file system
+// already contains the file with the same data, so that rewriting
+// unchanged result files in initialization mode can be skipped
+func unchanged(path string, data []byte, opt *optionSet) bool {
+	r, ok := opt.fs.(fileReader)
+	if !ok {
+		return false
+	}
+	existing, err := r.ReadFile(path)
+	return err == nil && bytes.Equal(existing, data)
+}
+
+// remover is an optional interface that a WritableFS can implement
+// to support removal of stale result files
+type remover interface {
+	Remove(name string) error
+}
+
+// removeFile is an internal function that removes the file from
+// the configured file system, if it supports removal; missing files are ignored
+func removeFile(path string, opt *optionSet) error {
+	r, ok := opt.fs.(remover)
+	if !ok {
+		return nil
+	}
+	if opt.confine {
+		if err := checkConfined(path, opt.roots); err != nil {
+			return err
+		}
+	}
+	err := withRetry(opt, func() error {
+		return r.Remove(path)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// osFS writes files directly to the local file system
+type osFS struct{}
+
+func (osFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return writeFileMode(name, data, perm)
+}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// writeFileMode is an internal function that writes the file
+// and sets its permissions regardless of the umask. An existing
+// read-only file is made writable first.
+func writeFileMode(name string, data []byte, perm os.FileMode) error {
+	if fi, err := os.Stat(name); err == nil && fi.Mode().Perm()&0200 == 0 {
+		if err := os.Chmod(name, fi.Mode().Perm()|0200); err != nil {
+			return err
+		}
+	}
+	if err := ioutil.WriteFile(name, data, perm); err != nil {
+		return err
+	}
+	return os.Chmod(name, perm)
+}
+
+// DirFS returns a WritableFS that writes files into the `root`
+// directory, mirroring their original paths inside it
+func DirFS(root string) WritableFS {
+	return dirFS(root)
+}
+
+type dirFS string
+
+func (d dirFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(filepath.Join(string(d), path), perm)
+}
+
+func (d dirFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return writeFileMode(filepath.Join(string(d), name), data, perm)
+}
+
+func (d dirFS) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(string(d), name))
+}
+
+func (d dirFS) Remove(name string) error {
+	return os.Remove(filepath.Join(string(d), name))
+}
+
+// MemFS is an in-memory WritableFS which keeps all written files
+// in memory. It is safe for concurrent use.
+type MemFS struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemFS creates an empty in-memory file system
+func NewMemFS() *MemFS {
+	return &MemFS{files: make(map[string][]byte)}
+}
+
+// MkdirAll is a no-op, as MemFS has no notion of directories
+func (m *MemFS) MkdirAll(path string, perm os.FileMode) error {
+	return nil
+}
+
+// WriteFile stores a copy of the data under the cleaned file name
+func (m *MemFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[filepath.Clean(name)] = append([]byte(nil), data...)
+	return nil
+}
+
+// Remove deletes the previously written file
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, filepath.Clean(name))
+	return nil
+}
+
+// ReadFile returns the contents of the previously written file
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[filepath.Clean(name)]
+	if !ok {
+		return nil, errors.New("file does not exist: " + name)
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Files returns the sorted list of written file names
+func (m *MemFS) Files() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
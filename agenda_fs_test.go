@@ -0,0 +1,177 @@
+package agenda
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestOutputMemFS is a traditional (non agenda-based) test
+// that initializes snapshots into an in-memory file system
+// and verifies that they match the ones stored on disk
+func TestOutputMemFS(t *testing.T) {
+	fsys := NewMemFS()
+	Run(t, "testdata/01/default", test01, InitMode(true), Output(fsys))
+
+	names := fsys.Files()
+	if len(names) != 4 {
+		t.Fatalf("Expected 4 files, got %d: %v", len(names), names)
+	}
+
+	for _, name := range names {
+		data, err := fsys.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, ref) {
+			t.Errorf("Contents of '%s' don't match the reference file", name)
+		}
+	}
+}
+
+// TestOutputDirFS is a traditional (non agenda-based) test
+// that initializes snapshots into a staging directory
+func TestOutputDirFS(t *testing.T) {
+	root, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	Run(t, "testdata/01/custom-file-suffix", test01, InitMode(true), Output(DirFS(root)),
+		FileSuffix(".custom"))
+
+	for _, name := range []string{"1.custom.result", "2.custom.result", "3.custom.result"} {
+		path := filepath.Join("testdata/01/custom-file-suffix", name)
+		data, err := ioutil.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, ref) {
+			t.Errorf("Contents of '%s' don't match the reference file", name)
+		}
+	}
+}
+
+// TestFileMode is a traditional (non agenda-based) test that checks
+// the permissions of the written files and directories, and that read-only
+// result files can be updated
+func TestFileMode(t *testing.T) {
+	root, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	dir := "testdata/01/custom-file-suffix"
+	for i := 0; i < 2; i++ {
+		Run(t, dir, test01, InitMode(true), Output(DirFS(root)),
+			FileSuffix(".custom"), FileMode(0444), DirMode(0700))
+	}
+
+	fi, err := os.Stat(filepath.Join(root, dir, "1.custom.result"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0444 {
+		t.Errorf("Expected the result file mode to be 0444, got %#o", fi.Mode().Perm())
+	}
+
+	fi, err = os.Stat(filepath.Join(root, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0700 {
+		t.Errorf("Expected the directory mode to be 0700, got %#o", fi.Mode().Perm())
+	}
+}
+
+// TestRewriteReadOnly is a traditional (non agenda-based) test that checks
+// that read-only result files can be updated by their owner
+func TestRewriteReadOnly(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+
+	root, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	path := filepath.Join(root, "1.json.result")
+	if err := writeFileMode(path, []byte("old"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileMode(path, []byte("new"), 0444); err != nil {
+		t.Fatalf("Can't rewrite the read-only file: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("Expected the file to be rewritten, got %q", data)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0444 {
+		t.Errorf("Expected the file mode to be 0444, got %#o", fi.Mode().Perm())
+	}
+}
+
+// TestSkipUnchanged is a traditional (non agenda-based) test that checks
+// that unchanged result files are not rewritten in initialization mode
+func TestSkipUnchanged(t *testing.T) {
+	fsys := NewMemFS()
+	fsys.WriteFile("testdata/recursive/1.json.result", []byte("stale"), 0644)
+
+	res := RunWithResults(t, "testdata/recursive", test01, Recursive(), InitMode(true), Output(fsys))
+	if len(res.Cases[0].Written) != 1 {
+		t.Errorf("Expected the stale result file to be rewritten")
+	}
+
+	res = RunWithResults(t, "testdata/recursive", test01, Recursive(), InitMode(true), Output(fsys))
+	for _, c := range res.Cases {
+		if len(c.Written) != 0 {
+			t.Errorf("Expected unchanged result files to be skipped, got %v", c.Written)
+		}
+	}
+}
+
+// TestCheckConfined is a traditional (non agenda-based) test
+// that checks which paths are considered to be inside the test data directories
+func TestCheckConfined(t *testing.T) {
+	var tests = []struct {
+		path    string
+		allowed bool
+	}{
+		{"testdata/01/default/1.json.result", true},
+		{"testdata/01/default/sub/1.json.result", true},
+		{"./testdata/01/../01/default/1.json.result", true},
+		{"testdata/01/default/../../../agenda.go", false},
+		{"testdata/01/default-other/1.json.result", false},
+		{"/etc/passwd", false},
+	}
+
+	for _, test := range tests {
+		t.Log(test.path)
+		err := checkConfined(test.path, []string{"testdata/01/default"})
+		if allowed := err == nil; allowed != test.allowed {
+			t.Errorf("Expected allowed=%v, got %v (%v)", test.allowed, allowed, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package agenda
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// GitHubAnnotations makes agenda write a GitHub Actions workflow command
+// (`::error file=...,line=...::...`) to `w` for each mismatch between
+// the generated output and the reference data, pointing at the result file
+// and its first differing line, so that snapshot failures show up as inline
+// annotations in pull requests. In warn-only mode (see WarnOnly()),
+// `::warning` commands are written instead. File paths are relative
+// to the root of the Git repository.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.GitHubAnnotations(os.Stdout))
+func GitHubAnnotations(w io.Writer) option {
+	return func(o *optionSet) {
+		o.githubOutput = w
+	}
+}
+
+// compareFile is an internal function that compares the generated output
+// with the reference data of the result file (without the annotation header,
+// see Annotations()), writing the workflow command on mismatch
+func compareFile(t reporter, resultPath string, header, referenceOutput, output []byte, opt *optionSet) {
+	if opt.githubOutput == nil {
+		compareOutput(t, resultPath, referenceOutput, output, opt)
+		return
+	}
+
+	fc := &failureCounter{reporter: t}
+	compareOutput(fc, resultPath, referenceOutput, output, opt)
+	if fc.failures > 0 {
+		line := len(patchLines(header)) + firstDifferentLine(referenceOutput, output)
+		writeGitHubAnnotation(resultPath, line, opt)
+	}
+}
+
+// writeGitHubAnnotation is an internal function that writes the workflow
+// command annotating the line of the result file
+func writeGitHubAnnotation(resultPath string, line int, opt *optionSet) {
+	command := "error"
+	if opt.warnings != nil {
+		command = "warning"
+	}
+
+	path := resultPath
+	if abs, err := filepath.Abs(resultPath); err == nil {
+		if rel, err := filepath.Rel(repoRoot(), abs); err == nil {
+			path = rel
+		}
+	}
+
+	fmt.Fprintf(opt.githubOutput, "::%s file=%s,line=%d,title=%s::%s\n", command,
+		escapeProperty(filepath.ToSlash(path)),
+		line,
+		escapeProperty("Snapshot mismatch"),
+		escapeData(fmt.Sprintf("Reference %s contents don't match the generated output", resultPath)))
+}
+
+// firstDifferentLine is an internal function that returns
+// the one-based number of the first line that differs
+func firstDifferentLine(reference, output []byte) int {
+	a, b := patchLines(reference), patchLines(output)
+	line := 0
+	for line < len(a) && line < len(b) && a[line] == b[line] {
+		line++
+	}
+	if line >= len(a) && line > 0 {
+		// the output only adds lines: point at the last line of the file
+		line = len(a) - 1
+	}
+	return line + 1
+}
+
+// escapeData is an internal function that escapes
+// the message of the workflow command
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty is an internal function that escapes
+// the property value of the workflow command
+func escapeProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(escapeData(s))
+}
@@ -0,0 +1,86 @@
+package agenda
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestGitHubAnnotations is a traditional (non agenda-based) test that checks
+// that mismatches are reported as workflow commands pointing at the first differing line
+func TestGitHubAnnotations(t *testing.T) {
+	var buf bytes.Buffer
+	r := &fakeReporter{}
+	compareFile(r, "testdata/1.json.result", nil, []byte("a\nb\nc\n"), []byte("a\nx\nc\n"),
+		newOptionSet([]option{GitHubAnnotations(&buf)}))
+	if len(r.errors) != 1 {
+		t.Errorf("Expected the mismatch to be reported, got %v", r.errors)
+	}
+	want := "::error file=testdata/1.json.result,line=2,title=Snapshot mismatch::" +
+		"Reference testdata/1.json.result contents don't match the generated output\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected annotation:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	compareFile(r, "testdata/1.json.result", nil, []byte("a\n"), []byte("a\n"),
+		newOptionSet([]option{GitHubAnnotations(&buf)}))
+	if buf.Len() != 0 {
+		t.Errorf("Expected no annotation for matching data, got %q", buf.String())
+	}
+
+	buf.Reset()
+	compareFile(r, "testdata/1.json.result", nil, []byte("a\n"), []byte("b\n"),
+		newOptionSet([]option{GitHubAnnotations(&buf), WarnOnly()}))
+	if !bytes.HasPrefix(buf.Bytes(), []byte("::warning file=")) {
+		t.Errorf("Expected a warning annotation in warn-only mode, got %q", buf.String())
+	}
+
+	// the line numbers account for the annotation header
+	buf.Reset()
+	compareFile(r, "testdata/1.json.result", []byte("# note\n# note\n"), []byte("a\n"), []byte("b\n"),
+		newOptionSet([]option{GitHubAnnotations(&buf)}))
+	if !bytes.Contains(buf.Bytes(), []byte(",line=3,")) {
+		t.Errorf("Expected the annotation of line 3, got %q", buf.String())
+	}
+}
+
+// TestGitHubAnnotationsRetryFailedCases is a traditional (non agenda-based) test
+// that checks that the retried attempts are not annotated
+func TestGitHubAnnotationsRetryFailedCases(t *testing.T) {
+	calls := 0
+	flaky := func(path string, data []byte) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			return []byte("flaky"), nil
+		}
+		return test01(path, data)
+	}
+
+	var buf bytes.Buffer
+	Run(t, "testdata/shadow", flaky, Shadow(nil), RetryFailedCases(1), GitHubAnnotations(&buf))
+	// 1.json passes on retry, 2.json has a stale result file,
+	// and 3.json has none (which is not annotated)
+	if strings.Count(buf.String(), "::error ") != 1 || !strings.Contains(buf.String(), "2.json.result") {
+		t.Errorf("Expected only 2.json.result to be annotated, got %q", buf.String())
+	}
+}
+
+// TestFirstDifferentLine is a traditional (non agenda-based) test
+// that checks the line numbers of the annotations
+func TestFirstDifferentLine(t *testing.T) {
+	for _, tc := range []struct {
+		reference, output string
+		line              int
+	}{
+		{"a\nb\n", "a\nc\n", 2},
+		{"a\nb\n", "x\nb\n", 1},
+		{"a\nb\n", "a\nb\nc\n", 2},
+		{"a\nb\nc\n", "a\n", 2},
+		{"", "a\n", 1},
+	} {
+		if line := firstDifferentLine([]byte(tc.reference), []byte(tc.output)); line != tc.line {
+			t.Errorf("Expected line %d for %q vs %q, got %d", tc.line, tc.reference, tc.output, line)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package agenda
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// GroupTest defines the callback function of an agenda test whose cases
+// consist of several input files. It takes the case path (the directory
+// and the common prefix of the files) and the contents of the files keyed
+// by the rest of their names (e.g. "request.json" for "01.request.json"),
+// and returns the serialized output just like Test does.
+type GroupTest func(path string, files map[string][]byte) ([]byte, error)
+
+// RunGroups executes an agenda test function against all the input file groups
+// in the directory. Files sharing the prefix before the first dot (e.g.
+// "01.request.json", "01.config.json" and "01.body.bin") make up a single case,
+// so that complex scenarios don't need to embed binary payloads into
+// a single JSON input. The result file of the case is named after the prefix
+// (e.g. "01.result"). Hidden files, files without a dot in their name,
+// result files and their sidecars (e.g. "01.error" or "01.env"),
+// and manifest files are ignored. Input transformations (e.g. JSONC())
+// are applied to each file of the group.
+//
+// Example:
+//
+//		agenda.RunGroups(t, "./testdata/mytest", func(path string, files map[string][]byte) ([]byte, error) {
+//			return handle(files["request.json"], files["body.bin"])
+//		})
+func RunGroups(t *testing.T, dir string, test GroupTest, options ...option) {
+	if test == nil {
+		panic("test function is nil")
+	}
+
+	opt := newOptionSet(options)
+	guardInit(t, opt)
+	if opt.cachePath != "" {
+		// the cache keys don't cover the files of the groups
+		t.Fatal("RunGroups doesn't support the Cache() option")
+	}
+	registerDir(dir)
+	opt.roots = append(opt.roots, dir)
+	if opt.resultDir != "" {
+		opt.roots = append(opt.roots, opt.resultDir)
+	}
+
+	if opt.initMode {
+		logf(t, opt, logInfo, "Initializing snapshots for %s directory", dir)
+	} else {
+		logf(t, opt, logInfo, "Running snapshot-based tests for %s directory", dir)
+	}
+
+	cases, groups, err := listGroups(dir, opt)
+	if err != nil {
+		t.Fatalf("Can't read the directory contents: %v", err)
+	}
+	if len(cases) == 0 && !opt.initMode {
+		t.Fatalf("No file groups found in '%s' directory", dir)
+	}
+
+	cases = selectCases(t, cases, opt)
+	byPath := make(map[string][]*testCase)
+	for _, c := range cases {
+		byPath[c.path] = append(byPath[c.path], c)
+	}
+
+	// input transformations are applied to each file of the group
+	// instead of the (empty) data of the case
+	runOpt := *opt
+	runOpt.inputTransforms = nil
+	runCases(t, cases, func(path string, data []byte) ([]byte, error) {
+		files := make(map[string][]byte)
+		size := 0
+		for part, file := range groups[path] {
+			data, err := readFile(file, opt)
+			if err != nil {
+				return nil, fmt.Errorf("can't read the file: %v", err)
+			}
+			if data, err = transformInput(file, data, opt); err != nil {
+				return nil, err
+			}
+			files[part] = data
+			size += len(data)
+		}
+		for _, c := range byPath[path] {
+			c.size = size
+		}
+		return test(path, files)
+	}, &runOpt)
+}
+
+// listGroups is an internal function that walks the directory and returns
+// a case for each group of input files, along with the paths
+// of the group files keyed by the case path and the rest of their names
+func listGroups(dir string, opt *optionSet) ([]*testCase, map[string]map[string]string, error) {
+	groups := make(map[string]map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && !opt.recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		name := info.Name()
+		i := strings.Index(name, ".")
+		if i <= 0 || strings.HasSuffix(name, opt.resultSuffix) ||
+			name == manifestName || name == manifestName+signatureSuffix {
+			return nil
+		}
+		// skip the error snapshots, environment files and pending changes
+		if name[i:] == errorSuffix || name[i:] == envSuffix ||
+			strings.HasSuffix(name, opt.resultSuffix+pendingSuffix) {
+			return nil
+		}
+
+		key := filepath.Join(filepath.Dir(path), name[:i])
+		if groups[key] == nil {
+			groups[key] = make(map[string]string)
+		}
+		groups[key][name[i+1:]] = path
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var cases []*testCase
+	for _, key := range keys {
+		rel, err := filepath.Rel(dir, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		c := &testCase{
+			name:       filepath.ToSlash(rel),
+			path:       key,
+			resultPath: filepath.Join(resultRoot(dir, opt), rel) + opt.resultSuffix,
+			provided:   true,
+		}
+		cases = append(cases, c)
+	}
+	return cases, groups, nil
+}
@@ -0,0 +1,114 @@
+package agenda
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testGroup is a sample test callback function which lists
+// the files of the group with their sizes
+func testGroup(path string, files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s: %d bytes\n", name, len(files[name]))
+	}
+	return []byte(b.String()), nil
+}
+
+// TestRunGroups runs agenda tests whose cases consist of several files
+func TestRunGroups(t *testing.T) {
+	RunGroups(t, "testdata/groups", testGroup)
+}
+
+// TestListGroups is a traditional (non agenda-based) test
+// that checks how input files are grouped into cases
+func TestListGroups(t *testing.T) {
+	cases, groups, err := listGroups("testdata/groups", newOptionSet(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, c := range cases {
+		names = append(names, c.name+"="+c.resultPath)
+	}
+	if got, want := strings.Join(names, " "), "01=testdata/groups/01.result 02=testdata/groups/02.result"; got != want {
+		t.Errorf("Expected cases %q, got %q", want, got)
+	}
+	if got := groups["testdata/groups/01"]["body.bin"]; got != "testdata/groups/01.body.bin" {
+		t.Errorf("Unexpected group file path: %q", got)
+	}
+}
+
+// TestRunGroupsErrorSnapshots is a traditional (non agenda-based) test
+// that checks that error snapshots don't become the files of the group
+func TestRunGroupsErrorSnapshots(t *testing.T) {
+	dir := copyDir(t, "testdata/groups")
+	defer os.RemoveAll(dir)
+
+	var seen []string
+	failing := func(path string, files map[string][]byte) ([]byte, error) {
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		seen = append(seen, strings.Join(names, ","))
+		return nil, errors.New("can't process the group")
+	}
+
+	RunGroups(t, dir, failing, ErrorSnapshots(), InitMode(true))
+	if _, err := os.Stat(filepath.Join(dir, "01.error")); err != nil {
+		t.Fatalf("Expected the error snapshot to be written: %v", err)
+	}
+	RunGroups(t, dir, failing, ErrorSnapshots())
+
+	want := "body.bin,request.json config.json,request.json body.bin,request.json config.json,request.json"
+	if got := strings.Join(seen, " "); got != want {
+		t.Errorf("Expected the groups %q, got %q", want, got)
+	}
+}
+
+// TestRunGroupsTransformInput is a traditional (non agenda-based) test
+// that checks that input transformations are applied to each file
+// of the group, and that the size of the case is recorded
+func TestRunGroupsTransformInput(t *testing.T) {
+	suffix := []byte("!")
+	transform := TransformInput(func(path string, data []byte) ([]byte, error) {
+		return append(data[:len(data):len(data)], suffix...), nil
+	})
+	test := func(path string, files map[string][]byte) ([]byte, error) {
+		for name, data := range files {
+			if !bytes.HasSuffix(data, suffix) {
+				return nil, fmt.Errorf("file '%s' is not transformed", name)
+			}
+			files[name] = bytes.TrimSuffix(data, suffix)
+		}
+		return testGroup(path, files)
+	}
+
+	tracer := &recordingTracer{}
+	RunGroups(t, "testdata/groups", test, transform, Trace(context.Background(), tracer))
+
+	var sizes []string
+	for _, s := range tracer.spans[1:] {
+		sizes = append(sizes, fmt.Sprint(s.attrs["agenda.size"]))
+	}
+	// the sizes of the transformed files of each group
+	if got, want := strings.Join(sizes, " "), "28 36"; got != want {
+		t.Errorf("Expected the case sizes %q, got %q", want, got)
+	}
+}
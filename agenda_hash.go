@@ -0,0 +1,70 @@
+package agenda
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// HashOnly enables storing the SHA-256 digest and the size of the generated
+// output in result files instead of the output itself, for huge (usually
+// binary) outputs which are impractical to keep in the repository.
+// Result files then look like this:
+//
+//     sha256 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//     size 4
+//
+// If `dump` is true, the generated output of mismatching cases is saved
+// to a temporary file for inspection, and its path is logged.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.HashOnly(true))
+func HashOnly(dump bool) option {
+	return func(o *optionSet) {
+		o.hashOnly = true
+		o.hashDump = dump
+	}
+}
+
+// hashSummary is an internal function that returns the contents
+// of the result file for the output in hash-only mode
+func hashSummary(output []byte) []byte {
+	return []byte(fmt.Sprintf("sha256 %x\nsize %d\n", sha256.Sum256(output), len(output)))
+}
+
+// failureCounter is a reporter which counts the failures
+// reported through it
+type failureCounter struct {
+	reporter
+	failures int
+}
+
+func (c *failureCounter) Errorf(format string, args ...interface{}) {
+	c.failures++
+	c.reporter.Errorf(format, args...)
+}
+
+// checkHash is an internal function that checks or saves the hash summary
+// of the output (see HashOnly()), dumping the output on mismatch if requested
+func checkHash(t reporter, resultPath string, output []byte, opt *optionSet) {
+	fc := &failureCounter{reporter: t}
+	checkResult(fc, resultPath, hashSummary(output), opt)
+	if fc.failures == 0 || opt.initMode || !opt.hashDump {
+		return
+	}
+
+	f, err := ioutil.TempFile("", "agenda-*-"+filepath.Base(resultPath))
+	if err != nil {
+		t.Errorf("Can't save the generated output: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write(output); err != nil {
+		t.Errorf("Can't save the generated output: %v", err)
+		return
+	}
+	logf(t, opt, logAlways, "The generated output was saved to '%s'", f.Name())
+}
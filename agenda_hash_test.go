@@ -0,0 +1,65 @@
+package agenda
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testHuge is a sample test callback function
+// which generates the output of the requested size
+func testHuge(path string, data []byte) ([]byte, error) {
+	in := struct {
+		Size int `json:"size"`
+	}{}
+
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, in.Size)
+	for i := range out {
+		out[i] = byte(i * 31)
+	}
+	return out, nil
+}
+
+// TestHashOnly runs agenda tests which store hashes of the outputs
+func TestHashOnly(t *testing.T) {
+	Run(t, "testdata/hash-only", testHuge, HashOnly(false))
+}
+
+// TestHashOnlyDump is a traditional (non agenda-based) test
+// that checks that the mismatching output is saved for inspection
+func TestHashOnlyDump(t *testing.T) {
+	changed := func(path string, data []byte) ([]byte, error) {
+		return []byte("changed"), nil
+	}
+	c := &testCase{path: "testdata/hash-only/2.json", resultPath: "testdata/hash-only/2.json.result"}
+
+	opt := newOptionSet([]option{HashOnly(true)})
+	opt.initMode = false
+
+	var r fakeReporter
+	processFile(&r, c, changed, opt)
+	if len(r.errors) != 1 {
+		t.Fatalf("Expected 1 error, got %v", r.errors)
+	}
+
+	last := r.logs[len(r.logs)-1]
+	if !strings.HasPrefix(last, "The generated output was saved to '") {
+		t.Fatalf("Unexpected log message: %s", last)
+	}
+	path := strings.TrimSuffix(strings.TrimPrefix(last, "The generated output was saved to '"), "'")
+	defer os.Remove(path)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "changed" {
+		t.Errorf("Unexpected contents of the saved output: %q", data)
+	}
+}
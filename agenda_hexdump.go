@@ -0,0 +1,115 @@
+package agenda
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// HexdumpOption defines the option of the hexdump rendering
+// used by BinarySerializer()
+type HexdumpOption func(f *hexdumpFormat)
+
+// hexdumpFormat is an internal structure that describes
+// the layout of the hexdump
+type hexdumpFormat struct {
+	bytesPerRow    int
+	group          int
+	decimalOffsets bool
+	noASCII        bool
+}
+
+// HexBytesPerRow sets the number of bytes rendered on each row
+// of the hexdump (16 by default), e.g. to match fixed-size records
+func HexBytesPerRow(n int) HexdumpOption {
+	return func(f *hexdumpFormat) {
+		f.bytesPerRow = n
+	}
+}
+
+// HexGroup sets the number of bytes in a group separated
+// by an extra space (8 by default); 0 disables grouping
+func HexGroup(n int) HexdumpOption {
+	return func(f *hexdumpFormat) {
+		f.group = n
+	}
+}
+
+// HexDecimalOffsets renders row offsets as decimal numbers
+// instead of hexadecimal ones
+func HexDecimalOffsets() HexdumpOption {
+	return func(f *hexdumpFormat) {
+		f.decimalOffsets = true
+	}
+}
+
+// HexNoASCII suppresses the ASCII column of the hexdump
+func HexNoASCII() HexdumpOption {
+	return func(f *hexdumpFormat) {
+		f.noASCII = true
+	}
+}
+
+// newHexdumpFormat is an internal function that computes
+// the hexdump layout, starting from the layout of `hexdump -C`
+func newHexdumpFormat(options []HexdumpOption) *hexdumpFormat {
+	f := &hexdumpFormat{
+		bytesPerRow: 16,
+		group:       8,
+	}
+	for _, o := range options {
+		o(f)
+	}
+	if f.bytesPerRow < 1 {
+		f.bytesPerRow = 16
+	}
+	return f
+}
+
+// dump renders the data; with the default layout,
+// the output is identical to the one of hex.Dump()
+func (f *hexdumpFormat) dump(data []byte) string {
+	var buf bytes.Buffer
+	for offset := 0; offset < len(data); offset += f.bytesPerRow {
+		end := offset + f.bytesPerRow
+		if end > len(data) {
+			end = len(data)
+		}
+		row := data[offset:end]
+
+		var line strings.Builder
+		if f.decimalOffsets {
+			fmt.Fprintf(&line, "%08d  ", offset)
+		} else {
+			fmt.Fprintf(&line, "%08x  ", offset)
+		}
+
+		for i := 0; i < f.bytesPerRow; i++ {
+			if i < len(row) {
+				fmt.Fprintf(&line, "%02x ", row[i])
+			} else {
+				line.WriteString("   ")
+			}
+			if f.group > 0 && (i+1)%f.group == 0 && i+1 < f.bytesPerRow {
+				line.WriteByte(' ')
+			}
+		}
+
+		if f.noASCII {
+			buf.WriteString(strings.TrimRight(line.String(), " "))
+			buf.WriteByte('\n')
+			continue
+		}
+
+		line.WriteString(" |")
+		for _, b := range row {
+			if b < 32 || b > 126 {
+				b = '.'
+			}
+			line.WriteByte(b)
+		}
+		line.WriteString("|\n")
+		buf.WriteString(line.String())
+	}
+	return buf.String()
+}
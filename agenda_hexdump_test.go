@@ -0,0 +1,40 @@
+package agenda
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestHexdump runs agenda tests
+// to verify the hexdump rendering with custom layout
+func TestHexdump(t *testing.T) {
+	f := newHexdumpFormat([]HexdumpOption{HexBytesPerRow(12), HexGroup(4), HexDecimalOffsets()})
+	Run(t, "testdata/hexdump", func(path string, data []byte) ([]byte, error) {
+		return []byte(f.dump(data)), nil
+	}, FileSuffix(".bin"))
+}
+
+// TestHexdumpNoASCII runs agenda tests
+// to verify the hexdump rendering without the ASCII column
+func TestHexdumpNoASCII(t *testing.T) {
+	f := newHexdumpFormat([]HexdumpOption{HexGroup(0), HexNoASCII()})
+	Run(t, "testdata/hexdump", func(path string, data []byte) ([]byte, error) {
+		return []byte(f.dump(data)), nil
+	}, FileSuffix(".bin"), ResultSuffix(".noascii"))
+}
+
+// TestHexdumpDefault is a traditional (non agenda-based) test
+// that checks that the default layout matches hex.Dump()
+func TestHexdumpDefault(t *testing.T) {
+	f := newHexdumpFormat(nil)
+
+	data := make([]byte, 40)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	for n := 0; n <= len(data); n++ {
+		if got, want := f.dump(data[:n]), hex.Dump(data[:n]); got != want {
+			t.Errorf("Length %d: got\n%s\nwant\n%s", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package agenda
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// historyMu serializes access to history files
+// from Run calls executed in parallel
+var historyMu sync.Mutex
+
+// history is an internal structure that represents the contents
+// of the history file; cases are keyed by their input file path
+type history struct {
+	Cases map[string]*caseHistory `json:"cases"`
+}
+
+// caseHistory holds the outcome statistics of a single case
+type caseHistory struct {
+	Runs       int       `json:"runs"`
+	Failures   int       `json:"failures"`
+	LastFailed bool      `json:"lastFailed"`
+	LastRun    time.Time `json:"lastRun"`
+}
+
+// failureRate returns the historical failure rate of the case;
+// cases without history get a neutral rate
+func (h *caseHistory) failureRate() float64 {
+	if h == nil {
+		return 0.5
+	}
+	return float64(h.Failures+1) / float64(h.Runs+2)
+}
+
+// History allows you to specify the path to the file where
+// the outcome of each case (number of runs and failures, and whether
+// the last run failed) is recorded after every run. The history
+// is used by other options, e.g. Prioritize().
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.History("testdata/.history.json"))
+func History(path string) option {
+	return func(o *optionSet) {
+		o.historyPath = path
+	}
+}
+
+// Prioritize orders the execution of cases so that the most informative
+// ones run first: cases with the highest historical failure rate
+// (see History()) go first, followed by the cases whose input or result
+// files were modified most recently. Combined with `go test -failfast`,
+// this gives the fastest possible signal during development.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc,
+//     agenda.History("testdata/.history.json"), agenda.Prioritize())
+func Prioritize() option {
+	return func(o *optionSet) {
+		o.prioritize = true
+	}
+}
+
+// rerunFailedFlag enables RerunFailed() from the command line
+// (`go test -agenda.failed`)
+var rerunFailedFlag = flag.Bool("agenda.failed", false,
+	"run only the agenda cases that failed in the previous run (see agenda.RerunFailed())")
+
+// RerunFailed makes agenda run only the cases that failed the last time
+// they were run according to the history (see History()), shortening
+// the fix-verify loop on large corpora. The mode can also be enabled
+// from the command line with `go test -agenda.failed`, in which case
+// the Run calls without the history file are not affected.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc,
+//     agenda.History("testdata/.history.json"), agenda.RerunFailed())
+func RerunFailed() option {
+	return func(o *optionSet) {
+		o.rerunFailed = true
+	}
+}
+
+// FailedFirst is a variant of Prioritize() that orders the execution
+// of cases so that the ones that failed the last time they were run
+// according to the history (see History()) go first, giving the fastest
+// possible signal during local debugging of regressions. The relative
+// order of the cases is kept otherwise, unless Prioritize() is also
+// specified, in which case it orders the cases within both groups.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc,
+//     agenda.History("testdata/.history.json"), agenda.FailedFirst())
+func FailedFirst() option {
+	return func(o *optionSet) {
+		o.failedFirst = true
+	}
+}
+
+// readHistory is an internal function that reads the history file;
+// a missing file results in an empty history
+func readHistory(path string) (*history, error) {
+	h := &history{Cases: make(map[string]*caseHistory)}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, err
+	}
+	if h.Cases == nil {
+		h.Cases = make(map[string]*caseHistory)
+	}
+	return h, nil
+}
+
+// updateHistory is an internal function that records
+// the outcome of the cases that were run into the history file
+func updateHistory(t *testing.T, cases []*testCase, opt *optionSet) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	h, err := readHistory(opt.historyPath)
+	if err != nil {
+		t.Errorf("Can't read the history file: %v", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, c := range cases {
+		if !c.ran {
+			continue
+		}
+		ch := h.Cases[c.path]
+		if ch == nil {
+			ch = &caseHistory{}
+			h.Cases[c.path] = ch
+		}
+		// mismatches reported as warnings count as failures
+		failed := c.failed || c.warned
+		ch.Runs++
+		if failed {
+			ch.Failures++
+		}
+		ch.LastFailed = failed
+		ch.LastRun = now
+	}
+
+	data, err := json.MarshalIndent(h, "", "\t")
+	if err != nil {
+		t.Errorf("Can't serialize the history: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(opt.historyPath, data, 0644); err != nil {
+		t.Errorf("Can't save the history file: %v", err)
+	}
+}
+
+// prioritizeCases is an internal function that orders cases by whether
+// they failed the last time they were run (see FailedFirst()), and then
+// by their historical failure rate and modification time (see Prioritize())
+func prioritizeCases(cases []*testCase, h *history, opt *optionSet) {
+	p := &byPriority{
+		cases:    cases,
+		failed:   make([]bool, len(cases)),
+		rates:    make([]float64, len(cases)),
+		modTimes: make([]time.Time, len(cases)),
+	}
+	for i, c := range cases {
+		if opt.failedFirst {
+			p.failed[i] = h.Cases[c.path].lastFailed()
+		}
+		if opt.prioritize {
+			p.rates[i] = h.Cases[c.path].failureRate()
+			p.modTimes[i] = caseModTime(c)
+		}
+	}
+	sort.Stable(p)
+}
+
+// caseModTime returns the latest modification time
+// of the case input and result files
+func caseModTime(c *testCase) time.Time {
+	var mt time.Time
+	for _, path := range []string{c.path, c.resultPath} {
+		if fi, err := os.Stat(path); err == nil && fi.ModTime().After(mt) {
+			mt = fi.ModTime()
+		}
+	}
+	return mt
+}
+
+type byPriority struct {
+	cases    []*testCase
+	failed   []bool
+	rates    []float64
+	modTimes []time.Time
+}
+
+func (p *byPriority) Len() int {
+	return len(p.cases)
+}
+
+func (p *byPriority) Less(i, j int) bool {
+	if p.failed[i] != p.failed[j] {
+		return p.failed[i]
+	}
+	if p.rates[i] != p.rates[j] {
+		return p.rates[i] > p.rates[j]
+	}
+	return p.modTimes[i].After(p.modTimes[j])
+}
+
+func (p *byPriority) Swap(i, j int) {
+	p.cases[i], p.cases[j] = p.cases[j], p.cases[i]
+	p.failed[i], p.failed[j] = p.failed[j], p.failed[i]
+	p.rates[i], p.rates[j] = p.rates[j], p.rates[i]
+	p.modTimes[i], p.modTimes[j] = p.modTimes[j], p.modTimes[i]
+}
+
+// failedCases is an internal function that returns the cases
+// that failed the last time they were run (see RerunFailed())
+func failedCases(t *testing.T, cases []*testCase, opt *optionSet) []*testCase {
+	if opt.historyPath == "" {
+		t.Fatal("Can't select the failed cases without the history file (see History())")
+	}
+	h, err := readHistory(opt.historyPath)
+	if err != nil {
+		t.Fatalf("Can't read the history file: %v", err)
+	}
+
+	var failed []*testCase
+	for _, c := range cases {
+		if h.Cases[c.path].lastFailed() {
+			failed = append(failed, c)
+		}
+	}
+	logf(t, opt, logAlways, "Rerunning %d previously failed out of %d files", len(failed), len(cases))
+	return failed
+}
+
+// lastFailed returns true if the case failed the last time it was run
+func (h *caseHistory) lastFailed() bool {
+	return h != nil && h.LastFailed
+}
+
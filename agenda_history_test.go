@@ -0,0 +1,127 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestHistory is a traditional (non agenda-based) test
+// that verifies that case outcomes are recorded into the history file
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "history.json")
+	Run(t, "testdata/01/default", test01, History(path))
+	Run(t, "testdata/01/default", test01, History(path))
+
+	h, err := readHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Cases) != 4 {
+		t.Fatalf("Expected 4 cases in the history, got %d", len(h.Cases))
+	}
+
+	ch := h.Cases[filepath.Join("testdata/01/default", "1.json")]
+	if ch == nil || ch.Runs != 2 || ch.Failures != 0 || ch.LastFailed {
+		t.Errorf("Unexpected history record: %+v", ch)
+	}
+}
+
+// TestPrioritizeCases is a traditional (non agenda-based) test
+// that checks that the most frequently failing cases go first
+func TestPrioritizeCases(t *testing.T) {
+	cases := []*testCase{
+		{path: "a.json"},
+		{path: "b.json"},
+		{path: "c.json"},
+		{path: "d.json"},
+	}
+	h := &history{Cases: map[string]*caseHistory{
+		"a.json": {Runs: 10, Failures: 0},
+		"b.json": {Runs: 10, Failures: 5},
+		"d.json": {Runs: 10, Failures: 9},
+	}}
+
+	prioritizeCases(cases, h, &optionSet{prioritize: true})
+
+	var order string
+	for _, c := range cases {
+		order += c.path[:1]
+	}
+	if order != "dbca" {
+		t.Errorf("Expected 'dbca' order, got '%s'", order)
+	}
+
+	h.Cases["a.json"].LastFailed = true
+	prioritizeCases(cases, h, &optionSet{prioritize: true, failedFirst: true})
+
+	order = ""
+	for _, c := range cases {
+		order += c.path[:1]
+	}
+	if order != "adbc" {
+		t.Errorf("Expected 'adbc' order, got '%s'", order)
+	}
+}
+
+// TestRerunFailed is a traditional (non agenda-based) test that checks
+// that only the cases which failed in the previous run are rerun
+func TestRerunFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "history.json")
+
+	// failures are reported in shadow mode, so that this test doesn't fail
+	Run(t, "testdata/shadow", test01, Shadow(nil), History(path))
+
+	o := &recordingObserver{}
+	Run(t, "testdata/shadow", test01, Shadow(nil), History(path), RerunFailed(), Observe(o))
+	want := []string{
+		"start 2",
+		"fail testdata/shadow/2.json",
+		"fail testdata/shadow/3.json",
+	}
+	if strings.Join(o.events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected events:\n%s", strings.Join(o.events, "\n"))
+	}
+}
+
+// TestFailedFirst is a traditional (non agenda-based) test that checks
+// that the cases which failed the last time they were run are run first
+func TestFailedFirst(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "history.json")
+
+	data := `{"cases": {"testdata/shadow/3.json": {"runs": 1, "failures": 1, "lastFailed": true}}}`
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &recordingObserver{}
+	// failures are reported in shadow mode, so that this test doesn't fail
+	Run(t, "testdata/shadow", test01, Shadow(nil), History(path), FailedFirst(), Observe(o))
+	want := []string{
+		"start 3",
+		"fail testdata/shadow/3.json",
+		"pass testdata/shadow/1.json",
+		"fail testdata/shadow/2.json",
+	}
+	if strings.Join(o.events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected events:\n%s", strings.Join(o.events, "\n"))
+	}
+}
@@ -0,0 +1,84 @@
+package agenda
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// includeKey is the key of the JSON object which is replaced
+// with the contents of the referenced file (see Includes())
+const includeKey = "$include"
+
+// Includes enables the include mechanism for JSON input data files, so that
+// common large sub-documents can be shared across many input files instead
+// of being duplicated. Each object consisting of the single "$include" key
+// is replaced with the contents of the referenced JSON file (the path is
+// relative to the file containing the include); included files can have
+// includes too. The input is then passed to the test function re-encoded,
+// with object keys sorted.
+//
+// Example:
+//
+//		// 01.json: {"user": {"$include": "../common/user.json"}, "action": "delete"}
+//		agenda.Run(t, "./testdata/mytest", testFunc, agenda.Includes())
+func Includes() option {
+	return func(o *optionSet) {
+		o.inputTransforms = append(o.inputTransforms, func(path string, data []byte) ([]byte, error) {
+			v, err := decodeJSON(data)
+			if err != nil {
+				return nil, err
+			}
+			if v, err = resolveIncludes(path, v, []string{path}, o); err != nil {
+				return nil, err
+			}
+			return json.Marshal(v)
+		})
+	}
+}
+
+// resolveIncludes is an internal function that replaces the include objects
+// in the decoded JSON value of the file at `path`; `stack` holds the paths
+// of the files being included, to detect include cycles
+func resolveIncludes(path string, v interface{}, stack []string, opt *optionSet) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		ref, ok := v[includeKey].(string)
+		if !ok || len(v) != 1 {
+			for k, val := range v {
+				resolved, err := resolveIncludes(path, val, stack, opt)
+				if err != nil {
+					return nil, err
+				}
+				v[k] = resolved
+			}
+			return v, nil
+		}
+
+		included := filepath.Join(filepath.Dir(path), filepath.FromSlash(ref))
+		for _, p := range stack {
+			if filepath.Clean(p) == included {
+				return nil, fmt.Errorf("include cycle: '%s' includes '%s'", path, ref)
+			}
+		}
+		registerDir(filepath.Dir(included))
+		data, err := readFile(included, opt)
+		if err != nil {
+			return nil, fmt.Errorf("can't include '%s': %v", ref, err)
+		}
+		iv, err := decodeJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("can't include '%s': %v", ref, err)
+		}
+		return resolveIncludes(included, iv, append(stack, included), opt)
+	case []interface{}:
+		for i, val := range v {
+			resolved, err := resolveIncludes(path, val, stack, opt)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,28 @@
+package agenda
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestIncludes runs agenda tests with shared fixture includes
+func TestIncludes(t *testing.T) {
+	Run(t, "testdata/include", testJSONC, Includes())
+}
+
+// TestIncludeErrors is a traditional (non agenda-based) test
+// that checks that missing includes and include cycles are reported
+func TestIncludeErrors(t *testing.T) {
+	opt := newOptionSet([]option{Includes()})
+	transform := opt.inputTransforms[0]
+
+	_, err := transform("testdata/include/common/cycle-a.json", []byte(`{"$include": "cycle-b.json"}`))
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("Expected an include cycle error, got %v", err)
+	}
+
+	_, err = transform("testdata/include/1.json", []byte(`{"$include": "missing.json"}`))
+	if err == nil || !strings.Contains(err.Error(), "can't include 'missing.json'") {
+		t.Errorf("Expected a missing include error, got %v", err)
+	}
+}
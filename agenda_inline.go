@@ -0,0 +1,199 @@
+package agenda
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+// inlineMu serializes source rewrites made by Inline()
+var inlineMu sync.Mutex
+
+// inlineEdits keeps track of the source rewrites made in this process,
+// so that line numbers reported by the runtime (which refer to the
+// original source) can be mapped to the current contents of the file
+var inlineEdits = make(map[string][]inlineEdit)
+
+// inlineEdit describes a rewrite that shifted all lines
+// after `line` by `delta` lines
+type inlineEdit struct {
+	line  int
+	delta int
+}
+
+// Inline compares the generated output (`got`) with the expected value
+// which lives as a string literal in the test source, for small snapshots
+// where a separate file is overkill. `want` must point to a variable
+// initialized with a string literal. In initialization mode, the literal
+// is rewritten in place in the source file, and the variable is updated.
+// Output transformations (e.g. Mask()) are applied before the comparison,
+// so that masked values never get into the source.
+//
+// Example:
+//
+//		want := `{"result":3}`
+//		agenda.Inline(t, got, &want)
+//
+// Running `go test -args init` will replace the literal
+// with the actual contents of `got`.
+func Inline(t *testing.T, got []byte, want *string, options ...option) {
+	if want == nil {
+		panic("want is nil")
+	}
+
+	opt := newOptionSet(options)
+	guardInit(t, opt)
+
+	_, file, line, ok := runtime.Caller(1)
+	if !ok {
+		t.Fatalf("Can't determine the location of the Inline() call")
+	}
+
+	got, err := transformOutput(file, got, opt)
+	if err != nil {
+		t.Errorf("Can't transform the output: %v", err)
+		return
+	}
+
+	if !opt.initMode {
+		compareOutput(t, "inline snapshot", []byte(*want), got, opt)
+		return
+	}
+
+	logf(t, opt, logInfo, "Updating inline snapshot at %s:%d", file, line)
+	if err := rewriteInline(file, line, string(got)); err != nil {
+		t.Fatalf("Can't update the inline snapshot: %v", err)
+	}
+	*want = string(got)
+}
+
+// rewriteInline is an internal function that finds the Inline() call
+// at the given (original) line of the file, and replaces the string literal
+// the `want` variable was initialized with
+func rewriteInline(file string, line int, value string) error {
+	inlineMu.Lock()
+	defer inlineMu.Unlock()
+
+	for _, e := range inlineEdits[file] {
+		if line > e.line {
+			line += e.delta
+		}
+	}
+
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	lit, err := findInlineLiteral(fset, f, line)
+	if err != nil {
+		return err
+	}
+
+	start, end := fset.Position(lit.Pos()), fset.Position(lit.End())
+	quoted := quoteInline(value)
+
+	var out []byte
+	out = append(out, src[:start.Offset]...)
+	out = append(out, quoted...)
+	out = append(out, src[end.Offset:]...)
+
+	if err := ioutil.WriteFile(file, out, 0644); err != nil {
+		return err
+	}
+
+	delta := strings.Count(quoted, "\n") - strings.Count(lit.Value, "\n")
+	if delta != 0 {
+		inlineEdits[file] = append(inlineEdits[file], inlineEdit{end.Line, delta})
+	}
+	return nil
+}
+
+// findInlineLiteral is an internal function that locates the string literal
+// assigned to the variable passed by pointer to the Inline() call on the given line
+func findInlineLiteral(fset *token.FileSet, f *ast.File, line int) (*ast.BasicLit, error) {
+	var call *ast.CallExpr
+	ast.Inspect(f, func(n ast.Node) bool {
+		if call != nil {
+			return false
+		}
+		c, ok := n.(*ast.CallExpr)
+		if !ok || len(c.Args) < 3 {
+			return true
+		}
+		if fset.Position(c.Pos()).Line > line || fset.Position(c.End()).Line < line {
+			return true
+		}
+
+		switch fun := c.Fun.(type) {
+		case *ast.Ident:
+			if fun.Name == "Inline" {
+				call = c
+			}
+		case *ast.SelectorExpr:
+			if fun.Sel.Name == "Inline" {
+				call = c
+			}
+		}
+		return true
+	})
+	if call == nil {
+		return nil, fmt.Errorf("no Inline() call found at line %d", line)
+	}
+
+	ref, ok := call.Args[2].(*ast.UnaryExpr)
+	if !ok || ref.Op != token.AND {
+		return nil, errors.New("the expected value must be passed as &variable")
+	}
+	ident, ok := ref.X.(*ast.Ident)
+	if !ok || ident.Obj == nil {
+		return nil, errors.New("the expected value must be passed as &variable")
+	}
+
+	var value ast.Expr
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.AssignStmt:
+		for i, lhs := range decl.Lhs {
+			if id, ok := lhs.(*ast.Ident); ok && id.Name == ident.Name && i < len(decl.Rhs) {
+				value = decl.Rhs[i]
+			}
+		}
+	case *ast.ValueSpec:
+		for i, id := range decl.Names {
+			if id.Name == ident.Name && i < len(decl.Values) {
+				value = decl.Values[i]
+			}
+		}
+	}
+
+	lit, ok := value.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil, fmt.Errorf("variable '%s' must be initialized with a string literal", ident.Name)
+	}
+	return lit, nil
+}
+
+// quoteInline is an internal function that renders the value
+// as a Go string literal, preferring raw strings for multi-line values
+func quoteInline(s string) string {
+	if strings.Contains(s, "\n") && utf8.ValidString(s) &&
+		strconv.CanBackquote(strings.Replace(s, "\n", "", -1)) {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
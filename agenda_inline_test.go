@@ -0,0 +1,78 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const inlineSource = `package example
+
+func TestA(t *testing.T) {
+	want := "old"
+	agenda.Inline(t, got, &want)
+}
+
+func TestB(t *testing.T) {
+	var want = ` + "`old`" + `
+	agenda.Inline(t,
+		got, &want)
+}
+`
+
+const inlineResult = `package example
+
+func TestA(t *testing.T) {
+	want := ` + "`line 1\nline 2`" + `
+	agenda.Inline(t, got, &want)
+}
+
+func TestB(t *testing.T) {
+	var want = "with \"quotes\""
+	agenda.Inline(t,
+		got, &want)
+}
+`
+
+// TestInline runs an inline snapshot test in regular mode
+func TestInline(t *testing.T) {
+	want := `{"result":3}`
+	Inline(t, []byte(`{"result":3}`), &want)
+}
+
+// TestRewriteInline is a traditional (non agenda-based) test
+// that checks that inline snapshot literals are rewritten in place,
+// including the line shifts caused by multi-line literals
+func TestRewriteInline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "example_test.go")
+	if err := ioutil.WriteFile(file, []byte(inlineSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// line numbers refer to the original source
+	if err := rewriteInline(file, 5, "line 1\nline 2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rewriteInline(file, 11, `with "quotes"`); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != inlineResult {
+		t.Errorf("Unexpected result:\n%s", data)
+	}
+
+	if err := rewriteInline(file, 3, "x"); err == nil {
+		t.Error("Expected an error for a line without Inline() call")
+	}
+}
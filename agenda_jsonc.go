@@ -0,0 +1,91 @@
+package agenda
+
+import (
+	"encoding/json"
+)
+
+// JSONC enables parsing of the input data files written in JSONC (JSON with
+// comments): `//` and `/* */` comments and trailing commas are removed
+// before the data is passed to the test function, so that humans maintaining
+// large fixture sets can annotate cases inline. Line and column numbers
+// in the parsing errors of the test function stay intact.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.JSONC())
+func JSONC() option {
+	return func(o *optionSet) {
+		o.inputTransforms = append(o.inputTransforms, func(path string, data []byte) ([]byte, error) {
+			return StripJSONC(data), nil
+		})
+	}
+}
+
+// StripJSONC converts JSONC (JSON with comments and trailing commas)
+// into standard JSON by replacing comments and trailing commas with spaces.
+// Newlines are preserved, so that positions in the data don't change.
+func StripJSONC(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+
+	// pass 1: blank out the comments
+	for i := 0; i < len(out); i++ {
+		switch {
+		case out[i] == '"':
+			i = skipJSONString(out, i)
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+			out[i], out[i+1] = ' ', ' '
+			for i += 2; i < len(out); i++ {
+				if out[i] == '*' && i+1 < len(out) && out[i+1] == '/' {
+					out[i], out[i+1] = ' ', ' '
+					i++
+					break
+				}
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+		}
+	}
+
+	// pass 2: blank out the trailing commas
+	for i := 0; i < len(out); i++ {
+		switch out[i] {
+		case '"':
+			i = skipJSONString(out, i)
+		case ',':
+			j := i + 1
+			for j < len(out) && (out[j] == ' ' || out[j] == '\t' || out[j] == '\r' || out[j] == '\n') {
+				j++
+			}
+			if j < len(out) && (out[j] == ']' || out[j] == '}') {
+				out[i] = ' '
+			}
+		}
+	}
+	return out
+}
+
+// skipJSONString is an internal function that returns the index
+// of the closing quote of the string starting at `i`
+func skipJSONString(data []byte, i int) int {
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return i
+}
+
+// UnmarshalJSONC parses the JSONC data (see StripJSONC())
+// and stores the result in the value pointed to by `v`
+func UnmarshalJSONC(data []byte, v interface{}) error {
+	return json.Unmarshal(StripJSONC(data), v)
+}
@@ -0,0 +1,48 @@
+package agenda
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// testJSONC is a sample test callback function which re-encodes
+// the input data in the canonical form
+func testJSONC(path string, data []byte) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(v, "", "\t")
+}
+
+// TestJSONC runs agenda tests with inputs written in JSONC
+func TestJSONC(t *testing.T) {
+	Run(t, "testdata/jsonc", testJSONC, JSONC())
+}
+
+// TestStripJSONC is a traditional (non agenda-based) test
+// that checks the conversion of JSONC into JSON
+func TestStripJSONC(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want string
+	}{
+		{`{"a":1}`, `{"a":1}`},
+		{"[1, 2,\n]", "[1, 2 \n]"},
+		{`{"a":"//","b":"/*"} // c`, `{"a":"//","b":"/*"}     `},
+		{"/* a\nb */[]", "    \n    []"},
+		{`["\"//", 1]`, `["\"//", 1]`},
+		{`{"a":[1,],}`, `{"a":[1 ] }`},
+	}
+
+	for _, test := range tests {
+		if got := string(StripJSONC([]byte(test.in))); got != test.want {
+			t.Errorf("Expected %q, got %q", test.want, got)
+		}
+	}
+
+	var v struct{ A []int }
+	if err := UnmarshalJSONC([]byte("{\"a\": [1, 2, 3,], // numbers\n}"), &v); err != nil || len(v.A) != 3 {
+		t.Errorf("Unexpected result: %v, %v", v, err)
+	}
+}
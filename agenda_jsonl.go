@@ -0,0 +1,70 @@
+package agenda
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+// RunLines executes an agenda test function against each line of the JSON Lines
+// (".jsonl") input data files in the directory, so that huge generated corpora
+// of tiny cases don't require a file per case. Each non-empty line becomes
+// a separate subtest named after its line number, and the test function is called
+// with the line as raw JSON data. The result file is stored as JSON Lines too:
+// each line holds the output generated for the input line at the same position
+// (outputs which are valid JSON are stored compacted, other outputs are stored
+// as JSON strings). Like RunEach, it doesn't support the options tracking
+// the outcome of whole cases (e.g. Observe(), History() or Cache()).
+//
+// Example:
+//
+//		// 01.jsonl:           01.jsonl.result:
+//		// {"a":1,"b":2}       {"result":3}
+//		// {"a":3,"b":4}       {"result":7}
+//		agenda.RunLines(t, "./testdata/mytest", testFunc)
+func RunLines(t *testing.T, dir string, test Test, options ...option) {
+	if test == nil {
+		panic("test function is nil")
+	}
+
+	opt := newOptionSet(append([]option{FileSuffix(".jsonl")}, options...))
+	runElements(t, "RunLines", dir, test, opt, jsonLinesFormat{})
+}
+
+// jsonLinesFormat stores the elements as JSON Lines (see RunLines())
+type jsonLinesFormat struct{}
+
+func (jsonLinesFormat) split(data []byte) ([]string, []json.RawMessage, error) {
+	var names []string
+	var elements []json.RawMessage
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return nil, nil, fmt.Errorf("line %d is not valid JSON", i+1)
+		}
+		names = append(names, strconv.Itoa(i+1))
+		elements = append(elements, line)
+	}
+	return names, elements, nil
+}
+
+func (f jsonLinesFormat) parse(data []byte) ([]json.RawMessage, error) {
+	_, elements, err := f.split(data)
+	return elements, err
+}
+
+func (jsonLinesFormat) join(elements []json.RawMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, element := range elements {
+		if err := json.Compact(&buf, element); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
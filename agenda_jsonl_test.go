@@ -0,0 +1,10 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// TestRunLines runs agenda tests for each line of the JSON Lines files
+func TestRunLines(t *testing.T) {
+	RunLines(t, "testdata/jsonl", test01)
+}
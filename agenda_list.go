@@ -0,0 +1,45 @@
+package agenda
+
+// Case describes a single input data file discovered in the test
+// directory (see List())
+type Case struct {
+	Name       string            // subtest name of the case
+	Path       string            // input data file
+	ResultPath string            // result file, which may not exist yet
+	Env        map[string]string // environment variables from the case list
+	Tags       []string
+	Skip       string // reason to skip the case, if any
+}
+
+// List returns the cases Run() would discover in the directory with
+// the same options, with their result paths resolved, so that custom
+// runners, generators and audits can reuse the discovery rules.
+// Selection options (e.g. Tags() or sampling) are not applied.
+//
+// Example:
+//
+//		cases, err := agenda.List("./testdata/mytest", agenda.ResultSuffix(".out"))
+//		for _, c := range cases {
+//			fmt.Println(c.Path, "=>", c.ResultPath)
+//		}
+func List(dir string, options ...option) ([]Case, error) {
+	opt := newOptionSet(options)
+
+	cases, err := listCases(dir, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Case, 0, len(cases))
+	for _, c := range cases {
+		list = append(list, Case{
+			Name:       c.name,
+			Path:       c.path,
+			ResultPath: c.resultPath,
+			Env:        c.env,
+			Tags:       c.tags,
+			Skip:       c.skip,
+		})
+	}
+	return list, nil
+}
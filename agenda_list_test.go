@@ -0,0 +1,39 @@
+package agenda
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestList is a traditional (non agenda-based) test that checks
+// the cases discovered in the test directories
+func TestList(t *testing.T) {
+	cases, err := List("testdata/01/custom-file-result-suffix", FileSuffix(".in"), ResultSuffix(".out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("Expected some cases to be found")
+	}
+	for _, c := range cases {
+		if filepath.Ext(c.Path) != ".in" || c.ResultPath != c.Path+".out" {
+			t.Errorf("Unexpected case: %+v", c)
+		}
+	}
+
+	cases, err = List("testdata/case-list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tagged bool
+	for _, c := range cases {
+		tagged = tagged || len(c.Tags) > 0
+	}
+	if !tagged {
+		t.Errorf("Expected tagged cases in the case list, got %+v", cases)
+	}
+
+	if _, err := List("testdata/nonexistent"); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,70 @@
+package agenda
+
+// LogPrinter defines the interface of the logger that receives the messages
+// of agenda tests instead of the test log (see the Logger() option).
+// It is implemented by *log.Logger, among others.
+type LogPrinter interface {
+	Printf(format string, args ...interface{})
+}
+
+// logLevel defines the verbosity of the messages
+type logLevel int
+
+const (
+	logAlways logLevel = iota - 1 // summaries, shown even in quiet mode
+	logInfo                       // per-file messages (default level)
+	logDebug                      // details, shown in verbose mode only
+)
+
+// Quiet silences per-file messages (the names of processed files,
+// written result files, etc.), leaving only failures and summaries
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Quiet())
+func Quiet() option {
+	return func(o *optionSet) {
+		o.logLevel = logAlways
+	}
+}
+
+// Verbose enables additional per-file messages
+// (e.g. the execution time of the test function)
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Verbose())
+func Verbose() option {
+	return func(o *optionSet) {
+		o.logLevel = logDebug
+	}
+}
+
+// Logger allows you to redirect the messages of agenda tests
+// from the test log to the provided logger. Failures are still
+// reported to the test.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Logger(log.New(os.Stderr, "agenda: ", 0)))
+func Logger(l LogPrinter) option {
+	return func(o *optionSet) {
+		o.logger = l
+	}
+}
+
+// logf is an internal function that logs the message of the given level
+// to the configured logger, or to the test log
+func logf(t reporter, opt *optionSet, level logLevel, format string, args ...interface{}) {
+	if level > opt.logLevel {
+		return
+	}
+	if opt.logger != nil {
+		opt.logger.Printf(format, args...)
+		return
+	}
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	t.Logf(format, args...)
+}
@@ -0,0 +1,36 @@
+package agenda
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+// TestLogger is a traditional (non agenda-based) test that checks
+// redirection of the messages and the effect of the logging levels
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.New(&buf, "", 0)
+
+	Run(t, "testdata/recursive", test01, Recursive(), Logger(l))
+	if !strings.Contains(buf.String(), "testdata/recursive/api/v2/3.json\n") {
+		t.Errorf("Expected file names to be logged, got:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "Test function finished") {
+		t.Errorf("Expected no verbose messages, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	Run(t, "testdata/recursive", test01, Recursive(), Logger(l), Verbose())
+	if !strings.Contains(buf.String(), "Test function finished") {
+		t.Errorf("Expected verbose messages, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	Run(t, "testdata/recursive", test01, Recursive(), Logger(l), Quiet(), Timing(1))
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 ||
+		!strings.HasPrefix(lines[0], "Total time: ") {
+		t.Errorf("Expected only the timing summary, got:\n%s", buf.String())
+	}
+}
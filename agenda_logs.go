@@ -0,0 +1,41 @@
+package agenda
+
+import (
+	"regexp"
+)
+
+// logNormalizers are the replacements applied by NormalizeLogs(),
+// in the order they are applied
+var logNormalizers = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`0x[0-9a-fA-F]{6,}`), "<pointer>"},
+	{timestampRe, timestampPlaceholder},
+	{regexp.MustCompile(`\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(\.\d+)?`), timestampPlaceholder},
+	{regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`), timestampPlaceholder},
+	{regexp.MustCompile(`\bgoroutine \d+\b`), "goroutine <id>"},
+	{regexp.MustCompile(`(?i)\b(pid[=: ]\s*)\d+\b`), "${1}<pid>"},
+	{regexp.MustCompile(`(\w)\[\d+\]:`), "${1}[<pid>]:"},
+	{regexp.MustCompile(`\b(\d+(\.\d+)?(ns|µs|us|ms|h|m|s))+\b`), "<duration>"},
+}
+
+// NormalizeLogs is a shortcut option that prepares multi-line log output
+// for snapshot testing: the volatile values commonly found in logs are
+// replaced with placeholders before the output is compared or saved:
+// timestamps (RFC 3339, Go's log package format and times of day) with
+// "<timestamp>", process IDs ("pid=123", "name[123]:") with "<pid>",
+// goroutine IDs with "<id>", durations ("1.5ms", "2m3s") with "<duration>"
+// and hexadecimal pointers with "<pointer>".
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.NormalizeLogs())
+func NormalizeLogs() option {
+	return TransformOutput(func(path string, data []byte) ([]byte, error) {
+		for _, n := range logNormalizers {
+			data = n.re.ReplaceAll(data, []byte(n.replacement))
+		}
+		return data, nil
+	})
+}
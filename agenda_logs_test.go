@@ -0,0 +1,17 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// TestNormalizeLogs takes the snapshot of the normalized log output
+func TestNormalizeLogs(t *testing.T) {
+	logs := `2021/03/04 05:06:07 server started, pid=4242
+2021-03-04T05:06:07.123456Z INFO request handled in 1.503ms (total 2m3.5s)
+05:06:08.250 worker[777]: processing item 12 of 40
+panic: runtime error: invalid memory address (addr=0xc000123abc)
+goroutine 17 [running]:
+main.handle(0xc0000a2000, 0x1)
+`
+	Match(t, []byte(logs), NormalizeLogs())
+}
@@ -0,0 +1,248 @@
+package agenda
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// manifestName is the name of the manifest file which lists the SHA-256
+// checksums of the result files in the test directory. Its format is
+// compatible with the output of `sha256sum`.
+const manifestName = "agenda.sum"
+
+// signatureSuffix is appended to the manifest file name to get
+// the name of the file with its detached signature
+const signatureSuffix = ".sig"
+
+// manifest maps cleaned result file paths to their SHA-256 checksums
+type manifest map[string]string
+
+// VerifySignature enables the verification of result files against
+// the signed manifest before they are compared with the generated output,
+// to guarantee that the reference data hasn't been tampered with between
+// the approval and the test run. The manifest ("agenda.sum", see BuildManifest())
+// in each test directory must have a valid detached signature ("agenda.sum.sig",
+// see SignManifest()) made with the private key matching `key`, and the checksum
+// of each result file must match the one in the manifest. Verification is skipped
+// in initialization mode; rebuild and sign the manifest after initializing
+// the snapshots.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.VerifySignature(publicKey))
+func VerifySignature(key ed25519.PublicKey) option {
+	return func(o *optionSet) {
+		o.verifyKey = key
+	}
+}
+
+// BuildManifest writes the manifest file ("agenda.sum") listing the SHA-256
+// checksums of all existing result (and error snapshot) files of the cases
+// in the directory. Options must match the ones used to run the tests.
+//
+// Example:
+//
+// err := agenda.BuildManifest("./testdata/mytest", agenda.ResultSuffix(".out"))
+func BuildManifest(dir string, options ...option) error {
+	opt := newOptionSet(options)
+
+	data, err := buildManifest(dir, ioutil.ReadFile, opt)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, manifestName), data, 0644)
+}
+
+// buildManifest is an internal function that renders the manifest
+// of the directory, reading the result files with `readFile`
+func buildManifest(dir string, readFile func(string) ([]byte, error), opt *optionSet) ([]byte, error) {
+	cases, err := listCases(dir, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, c := range cases {
+		for _, path := range []string{c.resultPath, c.errorPath(opt)} {
+			data, err := readFile(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, digest(data)+"  "+filepath.ToSlash(rel)+"\n")
+		}
+	}
+	sort.Strings(lines)
+
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// Checksums enables maintaining the manifest file ("agenda.sum", see
+// BuildManifest()) in each test directory, to catch accidental manual
+// edits of the reference data. The manifest is rebuilt after the snapshots
+// are initialized; in regular mode, a warning is logged for each result file
+// whose checksum doesn't match the manifest, or the test fails if `strict`
+// is true. Result files not listed in the manifest are not checked.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Checksums(true))
+func Checksums(strict bool) option {
+	return func(o *optionSet) {
+		o.checksums = true
+		o.checksumStrict = strict
+	}
+}
+
+// loadChecksums is an internal function that reads the (unsigned)
+// manifest of the directory, if it exists (see Checksums())
+func loadChecksums(dir string, opt *optionSet) error {
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	m, err := parseManifest(dir, data)
+	if err != nil {
+		return err
+	}
+	if opt.checksumList == nil {
+		opt.checksumList = make(manifest)
+	}
+	for path, sum := range m {
+		opt.checksumList[path] = sum
+	}
+	return nil
+}
+
+// updateManifest is an internal function that rebuilds the manifest
+// of the directory after the snapshots are initialized (see Checksums()).
+// Result files are read back from the output file system if it allows that.
+func updateManifest(dir string, opt *optionSet) error {
+	readFile := ioutil.ReadFile
+	if r, ok := opt.fs.(fileReader); ok {
+		readFile = func(path string) ([]byte, error) {
+			data, err := r.ReadFile(path)
+			if err != nil {
+				// files that can't be read back are treated as missing
+				return nil, os.ErrNotExist
+			}
+			return data, nil
+		}
+	}
+
+	data, err := buildManifest(dir, readFile, opt)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, manifestName), data, opt)
+}
+
+// SignManifest signs the manifest file of the directory with the Ed25519
+// private key and writes the detached signature (in a minisign-like
+// format: an untrusted comment line followed by the base64-encoded
+// signature) to the "agenda.sum.sig" file.
+func SignManifest(dir string, key ed25519.PrivateKey) error {
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return err
+	}
+
+	sig := ed25519.Sign(key, data)
+	out := "untrusted comment: agenda manifest signature\n" +
+		base64.StdEncoding.EncodeToString(sig) + "\n"
+
+	return ioutil.WriteFile(filepath.Join(dir, manifestName+signatureSuffix), []byte(out), 0644)
+}
+
+// verifyManifest is an internal function that verifies the signature
+// of the manifest of the directory, and returns its parsed contents
+func verifyManifest(dir string, key ed25519.PublicKey) (manifest, error) {
+	path := filepath.Join(dir, manifestName)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sigData, err := ioutil.ReadFile(path + signatureSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	var encoded string
+	for _, line := range strings.Split(string(sigData), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "untrusted comment:") {
+			encoded = line
+		}
+	}
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode the signature: %v", err)
+	}
+
+	if len(key) != ed25519.PublicKeySize || !ed25519.Verify(key, data, sig) {
+		return nil, fmt.Errorf("signature of '%s' is not valid", path)
+	}
+
+	return parseManifest(dir, data)
+}
+
+// parseManifest is an internal function that parses the manifest contents
+func parseManifest(dir string, data []byte) (manifest, error) {
+	m := make(manifest)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "  ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed manifest line: %q", line)
+		}
+		m[filepath.Join(dir, filepath.FromSlash(parts[1]))] = parts[0]
+	}
+	return m, scanner.Err()
+}
+
+// verifyReference is an internal function that checks the reference data
+// against the checksum recorded in the manifest, if verification is enabled
+func verifyReference(t reporter, path string, data []byte, opt *optionSet) error {
+	if expected, ok := opt.checksumList[filepath.Clean(path)]; ok && digest(data) != expected {
+		if opt.checksumStrict {
+			return errors.New("file checksum doesn't match the manifest (was it edited by hand?)")
+		}
+		logf(t, opt, logAlways, "Warning: checksum of '%s' doesn't match the manifest (was it edited by hand?)", path)
+	}
+
+	if opt.manifest == nil {
+		return nil
+	}
+
+	expected, ok := opt.manifest[filepath.Clean(path)]
+	if !ok {
+		return errors.New("file is not listed in the signed manifest")
+	}
+	if digest(data) != expected {
+		return errors.New("file checksum doesn't match the signed manifest")
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package agenda
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyDir is a helper function that copies the files
+// of the directory (non-recursively) into a new temporary directory
+func copyDir(t *testing.T, dir string) string {
+	return copyDirIn(t, "", dir)
+}
+
+// copyDirIn copies the files of the directory into
+// a new temporary directory created in `parent`
+func copyDirIn(t *testing.T, parent, dir string) string {
+	tmp, err := ioutil.TempDir(parent, "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(tmp, f.Name()), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmp
+}
+
+// TestVerifySignature is a traditional (non agenda-based) test
+// that signs the manifest of the result files, verifies it,
+// and checks that tampered result files are detected
+func TestVerifySignature(t *testing.T) {
+	dir := copyDir(t, "testdata/01/default")
+	defer os.RemoveAll(dir)
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := BuildManifest(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := SignManifest(dir, priv); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 4 {
+		t.Errorf("Expected 4 files in the manifest, got %d:\n%s", lines, data)
+	}
+
+	Run(t, dir, test01, VerifySignature(pub))
+
+	// tamper with one of the result files
+
+	resultPath := filepath.Join(dir, "1.json.result")
+	if err := ioutil.WriteFile(resultPath, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opt := newOptionSet([]option{VerifySignature(pub)})
+	opt.initMode = false
+	if opt.manifest, err = verifyManifest(dir, pub); err != nil {
+		t.Fatal(err)
+	}
+
+	var r fakeReporter
+	checkResult(&r, resultPath, []byte("{}"), opt)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "checksum doesn't match") {
+		t.Errorf("Expected a checksum error, got %v", r.errors)
+	}
+
+	// a different key must be rejected
+
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifyManifest(dir, otherPub); err == nil {
+		t.Error("Expected the signature verification to fail with a different key")
+	}
+}
+
+// TestChecksums is a traditional (non agenda-based) test that checks
+// that init mode maintains the manifest, and that hand-edited result
+// files are reported
+func TestChecksums(t *testing.T) {
+	dir := copyDir(t, "testdata/01/default")
+	defer os.RemoveAll(dir)
+
+	Run(t, dir, test01, InitMode(true), Checksums(true))
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 4 {
+		t.Errorf("Expected 4 entries in the manifest, got %d", n)
+	}
+
+	Run(t, dir, test01, InitMode(false), Checksums(true))
+
+	// edit the result file by hand, keeping its meaning
+	path := filepath.Join(dir, "1.json.result")
+	ref, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, append(ref, ' '), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	res := RunWithResults(t, dir, test01, InitMode(false), Checksums(false),
+		Logger(log.New(&buf, "", 0)), IgnoreWhitespace())
+	if res.Failed != 0 {
+		t.Errorf("Expected no failures in non-strict mode, got %+v", res.Summary)
+	}
+	if !strings.Contains(buf.String(), "Warning: checksum of '"+path+"' doesn't match the manifest") {
+		t.Errorf("Expected a warning, got:\n%s", buf.String())
+	}
+
+	res = RunWithResults(t, dir, test01, InitMode(false), Checksums(true),
+		IgnoreWhitespace(), Shadow(nil))
+	if res.Failed != 1 {
+		t.Errorf("Expected 1 failure in strict mode, got %+v", res.Summary)
+	}
+}
@@ -0,0 +1,33 @@
+package agenda
+
+import (
+	"bytes"
+)
+
+// Mask replaces all occurrences of the value (e.g. a secret token or
+// a volatile host name) in the generated output with the placeholder,
+// both before the output is saved as the reference and before it is
+// compared with it, so that the value never lands in the result files
+// (including error snapshots) or in the reported diffs. Masks are applied
+// after all output transformations, in the order the options are
+// specified. Empty values are ignored, so that the values can be taken
+// from optional environment variables.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Mask(os.Getenv("API_TOKEN"), "<token>"))
+func Mask(value, placeholder string) option {
+	return func(o *optionSet) {
+		if value != "" {
+			o.masks = append(o.masks, [2][]byte{[]byte(value), []byte(placeholder)})
+		}
+	}
+}
+
+// mask is an internal function that applies all configured masks to the data
+func mask(data []byte, opt *optionSet) []byte {
+	for _, m := range opt.masks {
+		data = bytes.Replace(data, m[0], m[1], -1)
+	}
+	return data
+}
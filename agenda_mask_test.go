@@ -0,0 +1,73 @@
+package agenda
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMask is a traditional (non agenda-based) test that checks
+// that masked values never get into the result files
+func TestMask(t *testing.T) {
+	dir := copyDir(t, "testdata/01/default")
+	defer os.RemoveAll(dir)
+
+	masks := []option{Mask("s3cr3t", "<token>"), Mask("", "<ignored>"), ErrorSnapshots()}
+	test := func(path string, data []byte) ([]byte, error) {
+		if strings.HasSuffix(path, "2.json") {
+			return nil, errors.New("can't authorize with s3cr3t")
+		}
+		return []byte("token: s3cr3t\n"), nil
+	}
+
+	Run(t, dir, test, append(masks, InitMode(true))...)
+
+	for name, expected := range map[string]string{
+		"1.json.result": "token: <token>\n",
+		"2.json.error":  "can't authorize with <token>",
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("Expected %q in '%s', got %q", expected, name, data)
+		}
+	}
+
+	Run(t, dir, test, append(masks, InitMode(false))...)
+}
+
+// TestMaskTree is a traditional (non agenda-based) test that checks
+// that the masked values don't land in the golden trees
+func TestMaskTree(t *testing.T) {
+	// the golden trees are directories, which are not copied
+	dir := copyDir(t, "testdata/tree")
+	defer os.RemoveAll(dir)
+
+	test := func(path string, data []byte, outDir string) error {
+		return ioutil.WriteFile(filepath.Join(outDir, "config"), []byte("token: s3cr3t\n"), 0644)
+	}
+
+	RunTree(t, dir, test, Mask("s3cr3t", "<token>"), InitMode(true))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "1.json.result", "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "token: <token>\n" {
+		t.Errorf("Expected the value to be masked, got %q", data)
+	}
+
+	RunTree(t, dir, test, Mask("s3cr3t", "<token>"), InitMode(false))
+}
+
+// TestMaskInline checks that the masked values are replaced
+// before the output is compared with the inline snapshot
+func TestMaskInline(t *testing.T) {
+	want := `token: <token>`
+	Inline(t, []byte("token: s3cr3t"), &want, Mask("s3cr3t", "<token>"))
+}
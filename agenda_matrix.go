@@ -0,0 +1,89 @@
+package agenda
+
+import (
+	"strings"
+)
+
+// Variant describes one combination of feature flags (or any other
+// environment settings) a case is run under. The name is used
+// as a subtest name and as a part of the result file name.
+type Variant struct {
+	Name string
+	Env  map[string]string
+}
+
+// Matrix allows you to run each case under each of the variants,
+// so that flag-guarded behavior is pinned for every combination
+// of flags from a single corpus. The environment variables of the variant
+// are set around the test function call (see CaseEnv()), and the result
+// of each variant is stored in a separate file with the variant name
+// inserted before the result suffix (e.g. "01.json.new-ui.result").
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Matrix(
+//     agenda.Variant{Name: "old-ui", Env: map[string]string{"NEW_UI": "0"}},
+//     agenda.Variant{Name: "new-ui", Env: map[string]string{"NEW_UI": "1"}},
+// ))
+func Matrix(variants ...Variant) option {
+	return func(o *optionSet) {
+		o.variants = variants
+	}
+}
+
+// FlagCombinations returns the variants for all on/off combinations
+// of the boolean flags, each passed as an environment variable
+// set to "1" or "0". Variants are named after the enabled flags joined
+// with '+', and "default" is used for the variant with all flags disabled.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc,
+//     agenda.Matrix(agenda.FlagCombinations("NEW_UI", "FAST_PATH")...))
+func FlagCombinations(flags ...string) []Variant {
+	variants := make([]Variant, 0, 1<<uint(len(flags)))
+	for mask := 0; mask < 1<<uint(len(flags)); mask++ {
+		v := Variant{Env: make(map[string]string)}
+		var enabled []string
+		for i, flag := range flags {
+			if mask&(1<<uint(i)) != 0 {
+				v.Env[flag] = "1"
+				enabled = append(enabled, flag)
+			} else {
+				v.Env[flag] = "0"
+			}
+		}
+		v.Name = strings.Join(enabled, "+")
+		if v.Name == "" {
+			v.Name = "default"
+		}
+		variants = append(variants, v)
+	}
+	return variants
+}
+
+// expandVariants is an internal function that replaces each case
+// with one case per variant
+func expandVariants(cases []*testCase, opt *optionSet) []*testCase {
+	if len(opt.variants) == 0 {
+		return cases
+	}
+
+	expanded := make([]*testCase, 0, len(cases)*len(opt.variants))
+	for _, c := range cases {
+		for _, v := range opt.variants {
+			vc := *c
+			vc.name = c.name + "/" + v.Name
+			vc.resultPath = strings.TrimSuffix(c.resultPath, opt.resultSuffix) + "." + v.Name + opt.resultSuffix
+			vc.env = make(map[string]string)
+			for k, val := range c.env {
+				vc.env[k] = val
+			}
+			for k, val := range v.Env {
+				vc.env[k] = val
+			}
+			expanded = append(expanded, &vc)
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,54 @@
+package agenda
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testFlagged is a sample test callback function
+// whose output depends on feature flags
+func testFlagged(path string, data []byte) ([]byte, error) {
+	in := struct {
+		Name string `json:"name"`
+	}{}
+
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	out := "Hello, " + in.Name
+	if os.Getenv("AGENDA_TEST_LOUD") == "1" {
+		out = strings.ToUpper(out)
+	}
+	if os.Getenv("AGENDA_TEST_EXCITED") == "1" {
+		out += "!"
+	}
+	return []byte(out), nil
+}
+
+// TestMatrix runs agenda tests under all combinations of feature flags
+func TestMatrix(t *testing.T) {
+	Run(t, "testdata/matrix", testFlagged,
+		Matrix(FlagCombinations("AGENDA_TEST_LOUD", "AGENDA_TEST_EXCITED")...))
+}
+
+// TestFlagCombinations is a traditional (non agenda-based) test that checks
+// the names and environment of the generated variants
+func TestFlagCombinations(t *testing.T) {
+	variants := FlagCombinations("A", "B")
+
+	want := []string{"default", "A", "B", "A+B"}
+	if len(variants) != len(want) {
+		t.Fatalf("Expected %d variants, got %d", len(want), len(variants))
+	}
+	for i, v := range variants {
+		if v.Name != want[i] {
+			t.Errorf("Variant %d: expected name %q, got %q", i, want[i], v.Name)
+		}
+	}
+	if variants[2].Env["A"] != "0" || variants[2].Env["B"] != "1" {
+		t.Errorf("Unexpected environment for variant %q: %v", variants[2].Name, variants[2].Env)
+	}
+}
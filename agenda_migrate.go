@@ -0,0 +1,86 @@
+package agenda
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// From allows you to specify the options of the previous layout
+// of the test directory to migrate from (see Migrate())
+func From(options ...option) option {
+	return func(o *optionSet) {
+		o.migrateFrom = append(o.migrateFrom, options...)
+	}
+}
+
+// Migrate renames the input data files and the existing result files
+// (along with their error snapshots and environment files) in the directory
+// when the FileSuffix(), ResultSuffix() or ResultDir() options change,
+// so that the options can evolve without manual scripting. The previous
+// layout is described only by the options passed with From() (so options
+// like Recursive() need to be passed there too), and the new one by the rest
+// of the options. Nothing is renamed if any of the target
+// files already exists. Directories with the "cases.json" file are not
+// supported, and manifests (see BuildManifest()) have to be rebuilt
+// afterwards.
+//
+// Example:
+//
+//		// 01.json.result -> 01.json.out
+//		err := agenda.Migrate("./testdata/mytest",
+//			agenda.From(agenda.ResultSuffix(".result")), agenda.ResultSuffix(".out"))
+func Migrate(dir string, options ...option) error {
+	opt := newOptionSet(options)
+	old := newOptionSet(opt.migrateFrom)
+
+	if _, err := os.Stat(filepath.Join(dir, caseListName)); err == nil {
+		return fmt.Errorf("directories with the '%s' file are not supported", caseListName)
+	}
+
+	cases, err := listCases(dir, old)
+	if err != nil {
+		return err
+	}
+
+	// plan all renames first, so that nothing is renamed
+	// if any of the target files already exists
+
+	var renames [][2]string
+	for _, c := range cases {
+		path := strings.TrimSuffix(c.path, old.fileSuffix) + opt.fileSuffix
+		nc, err := newFileCase(dir, path, opt)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range [][2]string{
+			{c.path, nc.path},
+			{c.path + envSuffix, nc.path + envSuffix},
+			{c.resultPath, nc.resultPath},
+			{c.errorPath(old), nc.errorPath(opt)},
+		} {
+			if r[0] == r[1] {
+				continue
+			}
+			if _, err := os.Stat(r[0]); os.IsNotExist(err) {
+				continue
+			}
+			if _, err := os.Stat(r[1]); err == nil {
+				return fmt.Errorf("can't rename '%s': file '%s' already exists", r[0], r[1])
+			}
+			renames = append(renames, r)
+		}
+	}
+
+	for _, r := range renames {
+		if err := os.MkdirAll(filepath.Dir(r[1]), opt.dirMode); err != nil {
+			return err
+		}
+		if err := os.Rename(r[0], r[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
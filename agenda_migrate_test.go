@@ -0,0 +1,47 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMigrate is a traditional (non agenda-based) test that migrates
+// a test directory to new suffixes and a separate result directory,
+// and checks that the tests still pass with the new options
+func TestMigrate(t *testing.T) {
+	dir := copyDir(t, "testdata/01/default")
+	defer os.RemoveAll(dir)
+	golden := filepath.Join(dir, "golden")
+
+	err := Migrate(dir, From(FileSuffix(".json"), ResultSuffix(".result")),
+		FileSuffix(".input"), ResultSuffix(".out"), ResultDir(golden))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"1.input", "4.input", "5.json.ignored", "golden/1.input.out", "golden/4.input.out"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected '%s' to exist: %v", name, err)
+		}
+	}
+	for _, name := range []string{"1.json", "1.json.result"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected '%s' to be renamed", name)
+		}
+	}
+
+	Run(t, dir, test01, FileSuffix(".input"), ResultSuffix(".out"), ResultDir(golden))
+
+	// migrating over the existing files is refused
+	if err := ioutil.WriteFile(filepath.Join(dir, "4.json"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(dir, From(FileSuffix(".input")), FileSuffix(".json")); err == nil {
+		t.Errorf("Expected an error when the target file exists")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1.input")); err != nil {
+		t.Errorf("Expected no files to be renamed: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package agenda
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxNameLen is the maximum length (in bytes) of a single
+// element of a sanitized case name
+const maxNameLen = 100
+
+// SanitizeNames enables sanitization of case names before they are used
+// to build subtest names and result file paths. This is recommended
+// for corpora imported or recorded from external sources: control characters,
+// backslashes and invalid UTF-8 are replaced, "." and ".." path elements
+// are neutralized, and overly long names are truncated. Each modified
+// name element gets a short hash of its original value appended, so that
+// different names never collide after sanitization. Slashes are preserved
+// and still group cases into nested subtests.
+//
+// Example:
+//
+// agenda.RunCases(t, importedCorpus, testFunc, agenda.ResultDir("testdata/imported"),
+//     agenda.SanitizeNames())
+func SanitizeNames() option {
+	return func(o *optionSet) {
+		o.sanitize = true
+	}
+}
+
+// sanitizeName is an internal function that makes a slash-separated
+// case name safe to use as a relative file path and a subtest name
+func sanitizeName(name string) string {
+	parts := strings.Split(name, "/")
+	out := parts[:0]
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		out = append(out, sanitizeNameElement(part))
+	}
+	if len(out) == 0 {
+		return sanitizeNameElement("")
+	}
+	return strings.Join(out, "/")
+}
+
+// sanitizeNameElement is an internal function that sanitizes
+// a single element of the case name
+func sanitizeNameElement(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				b.WriteRune('_')
+				continue
+			}
+		}
+		if unicode.IsControl(r) || r == '\\' || r == ':' {
+			b.WriteRune('_')
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	clean := b.String()
+	switch clean {
+	case "":
+		clean = "_"
+	case ".", "..":
+		clean = strings.Repeat("_", len(clean))
+	}
+	if clean == s && len(clean) <= maxNameLen {
+		return clean
+	}
+
+	sum := sha1.Sum([]byte(s))
+	suffix := "~" + hex.EncodeToString(sum[:4])
+	if len(clean) > maxNameLen-len(suffix) {
+		clean = truncateUTF8(clean, maxNameLen-len(suffix))
+	}
+	return clean + suffix
+}
+
+// truncateUTF8 truncates the string to at most n bytes
+// without breaking multi-byte characters
+func truncateUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,43 @@
+package agenda
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSanitizeName is a traditional (non agenda-based) test
+// that tests sanitizeName function
+func TestSanitizeName(t *testing.T) {
+	var tests = []struct {
+		name   string
+		result string
+	}{
+		{"case01.json", "case01.json"},
+		{"api/v2/case01.json", "api/v2/case01.json"},
+		{"//api///case01.json", "api/case01.json"},
+		{"../../etc/passwd", "__~9d891e73/__~9d891e73/etc/passwd"},
+		{"./case", "_~3a52ce78/case"},
+		{"a\\b", "a_b~d0dcc0f2"},
+		{"a\x01b", "a_b~493640e3"},
+		{"a\x02b", "a_b~cf09978e"},
+		{"c:", "c_~a8a3bd98"},
+		{"bad\xffutf8", "bad_utf8~be77423c"},
+		{"", "_~da39a3ee"},
+	}
+
+	for _, test := range tests {
+		t.Logf("%q", test.name)
+		if result := sanitizeName(test.name); result != test.result {
+			t.Errorf("Expected '%s', got '%s'", test.result, result)
+		}
+	}
+
+	long := strings.Repeat("я", 80)
+	result := sanitizeName(long)
+	if len(result) > maxNameLen {
+		t.Errorf("Expected at most %d bytes, got %d", maxNameLen, len(result))
+	}
+	if result == sanitizeName(long+"x") {
+		t.Error("Expected different results for different long names")
+	}
+}
@@ -0,0 +1,85 @@
+package agenda
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// normalizer is an internal function type that transforms the reference
+// data and the generated output before they are compared. It gets both
+// of them at once, so it can normalize one relative to the other.
+type normalizer func(reference, output []byte) ([]byte, []byte)
+
+// normalize is an internal function that applies all configured
+// normalizers to the reference data and the generated output
+func normalize(reference, output []byte, opt *optionSet) ([]byte, []byte) {
+	for _, f := range opt.normalizers {
+		reference, output = f(reference, output)
+	}
+	return reference, output
+}
+
+// IgnoreWhitespace enables the whitespace-insensitive comparison mode
+// for text outputs (e.g. generated SQL or HTML) where indentation is not
+// meaningful: leading and trailing whitespace of each line is trimmed,
+// runs of whitespace inside lines are collapsed into a single space,
+// and blank lines are ignored. Result files are still saved as is.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.IgnoreWhitespace())
+func IgnoreWhitespace() option {
+	return func(o *optionSet) {
+		o.normalizers = append(o.normalizers, func(reference, output []byte) ([]byte, []byte) {
+			return collapseWhitespace(reference), collapseWhitespace(output)
+		})
+	}
+}
+
+// collapseWhitespace is an internal function that removes
+// insignificant whitespace from the text (see IgnoreWhitespace())
+func collapseWhitespace(data []byte) []byte {
+	var buf bytes.Buffer
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		fields := bytes.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		buf.Write(bytes.Join(fields, []byte(" ")))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// IgnoreLines excludes the lines matching the regular expression from
+// both the reference data and the generated output before they are
+// compared, so they don't cause failures and don't show up in the diff
+// (e.g. for volatile headers of text outputs). Result files are still
+// saved as is.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.IgnoreLines(regexp.MustCompile(`^# generated at `)))
+func IgnoreLines(re *regexp.Regexp) option {
+	if re == nil {
+		panic("regular expression is nil")
+	}
+	return func(o *optionSet) {
+		o.normalizers = append(o.normalizers, func(reference, output []byte) ([]byte, []byte) {
+			return removeLines(reference, re), removeLines(output, re)
+		})
+	}
+}
+
+// removeLines is an internal function that removes the lines
+// matching the regular expression from the text (see IgnoreLines())
+func removeLines(data []byte, re *regexp.Regexp) []byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	out := lines[:0]
+	for _, line := range lines {
+		if len(line) > 0 && !re.Match(bytes.TrimSuffix(line, []byte("\n"))) {
+			out = append(out, line)
+		}
+	}
+	return bytes.Join(out, nil)
+}
@@ -0,0 +1,49 @@
+package agenda
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestIgnoreWhitespace is a traditional (non agenda-based) test
+// that compares outputs which differ in whitespace only
+func TestIgnoreWhitespace(t *testing.T) {
+	reference := []byte("SELECT *\n  FROM users\n  WHERE id = 1\n")
+	output := []byte("SELECT *\nFROM   users\n\n\tWHERE id = 1")
+
+	var r fakeReporter
+	compareOutput(&r, "query.sql.result", reference, output, newOptionSet([]option{IgnoreWhitespace()}))
+	if len(r.errors) != 0 {
+		t.Errorf("Expected no differences, got %v", r.errors)
+	}
+
+	output = []byte("SELECT *\nFROM users\nWHERE id = 2\n")
+	compareOutput(&r, "query.sql.result", reference, output, newOptionSet([]option{IgnoreWhitespace()}))
+	if len(r.errors) != 1 {
+		t.Errorf("Expected 1 error, got %v", r.errors)
+	}
+}
+
+// TestIgnoreLines is a traditional (non agenda-based) test that checks
+// that the ignored lines don't affect the comparison and the diff
+func TestIgnoreLines(t *testing.T) {
+	opt := newOptionSet([]option{IgnoreLines(regexp.MustCompile(`^# generated at `))})
+	reference := []byte("# generated at 10:00\nfoo\nbar\n")
+	output := []byte("# generated at 11:30\nfoo\nbar\n")
+
+	var r fakeReporter
+	compareOutput(&r, "out.txt.result", reference, output, opt)
+	if len(r.errors) != 0 {
+		t.Errorf("Expected no differences, got %v", r.errors)
+	}
+
+	output = []byte("# generated at 11:30\nfoo\nbaz\n")
+	compareOutput(&r, "out.txt.result", reference, output, opt)
+	if len(r.errors) != 1 {
+		t.Fatalf("Expected 1 error, got %v", r.errors)
+	}
+	if strings.Contains(r.errors[0], "generated at") {
+		t.Errorf("Expected the ignored lines to be excluded from the diff, got:\n%s", r.errors[0])
+	}
+}
@@ -0,0 +1,94 @@
+package agenda
+
+import (
+	"strings"
+	"time"
+)
+
+// Observer defines the interface of the listener that gets notified
+// about the lifecycle events of agenda tests, so that external reporting,
+// metrics or notification tooling can be integrated without scraping
+// the test output. Embed NopObserver to implement only some of the methods.
+// The methods are called from the goroutine running the test.
+type Observer interface {
+	// OnRunStart is called before running `total` selected cases
+	OnRunStart(total int)
+	// OnCaseStart is called before processing the case
+	OnCaseStart(path string)
+	// OnCasePass is called when the case passes in test mode
+	OnCasePass(path string)
+	// OnCaseFail is called when the case fails; the report contains
+	// all failure messages of the case, including the diffs
+	OnCaseFail(path string, report string)
+	// OnCaseInit is called when the snapshots of the case
+	// are written in initialization mode
+	OnCaseInit(path string)
+	// OnRunEnd is called after all cases are processed
+	OnRunEnd(summary Summary)
+}
+
+// NopObserver is an Observer which ignores all events
+type NopObserver struct{}
+
+func (NopObserver) OnRunStart(total int)                  {}
+func (NopObserver) OnCaseStart(path string)               {}
+func (NopObserver) OnCasePass(path string)                {}
+func (NopObserver) OnCaseFail(path string, report string) {}
+func (NopObserver) OnCaseInit(path string)                {}
+func (NopObserver) OnRunEnd(summary Summary)              {}
+
+// Summary describes the outcome of the run
+type Summary struct {
+	Total       int // the number of cases processed
+	Passed      int
+	Failed      int
+	Initialized int // the number of cases processed in initialization mode
+	Duration    time.Duration
+}
+
+// Observe registers the observer of the lifecycle events.
+// The option can be used multiple times to register several observers.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Observe(metricsObserver))
+func Observe(o Observer) option {
+	return func(opt *optionSet) {
+		opt.observers = append(opt.observers, o)
+	}
+}
+
+// notifyCase is an internal function that notifies the observers
+// about the outcome of the processed case
+func notifyCase(c *testCase, failures []string, opt *optionSet) {
+	for _, o := range opt.observers {
+		switch {
+		case c.failed:
+			o.OnCaseFail(c.path, strings.Join(failures, "\n"))
+		case opt.initMode:
+			o.OnCaseInit(c.path)
+		default:
+			o.OnCasePass(c.path)
+		}
+	}
+}
+
+// summarize is an internal function that computes
+// the summary of the run
+func summarize(cases []*testCase, start time.Time, opt *optionSet) Summary {
+	s := Summary{Duration: time.Since(start)}
+	for _, c := range cases {
+		switch c.status(opt) {
+		case StatusSkipped:
+			continue
+		case StatusFailed:
+			s.Failed++
+		case StatusInitialized:
+			s.Initialized++
+		default:
+			s.Passed++
+		}
+		s.Total++
+	}
+	return s
+}
@@ -0,0 +1,62 @@
+package agenda
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recordingObserver records the events it gets notified about
+type recordingObserver struct {
+	NopObserver
+	events  []string
+	summary Summary
+}
+
+func (o *recordingObserver) OnRunStart(total int) {
+	o.events = append(o.events, fmt.Sprintf("start %d", total))
+}
+
+func (o *recordingObserver) OnCasePass(path string) {
+	o.events = append(o.events, "pass "+path)
+}
+
+func (o *recordingObserver) OnCaseFail(path string, report string) {
+	o.events = append(o.events, "fail "+path)
+}
+
+func (o *recordingObserver) OnCaseInit(path string) {
+	o.events = append(o.events, "init "+path)
+}
+
+func (o *recordingObserver) OnRunEnd(summary Summary) {
+	o.summary = summary
+}
+
+// TestObserver is a traditional (non agenda-based) test
+// that checks the notifications of the observer
+func TestObserver(t *testing.T) {
+	o := &recordingObserver{}
+	Run(t, "testdata/recursive", test01, Recursive(), Observe(o))
+
+	want := []string{
+		"start 4",
+		"pass testdata/recursive/1.json",
+		"pass testdata/recursive/api/2.json",
+		"pass testdata/recursive/api/v2/3.json",
+		"pass testdata/recursive/api/v2/4.json",
+	}
+	if strings.Join(o.events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected events:\n%s", strings.Join(o.events, "\n"))
+	}
+	if o.summary.Total != 4 || o.summary.Passed != 4 || o.summary.Failed != 0 {
+		t.Errorf("Unexpected summary: %+v", o.summary)
+	}
+
+	// failures are reported in shadow mode, so that this test doesn't fail
+	o = &recordingObserver{}
+	Run(t, "testdata/shadow", test01, Shadow(nil), Observe(o))
+	if o.summary.Failed != 2 || o.summary.Passed != 1 {
+		t.Errorf("Unexpected summary: %+v", o.summary)
+	}
+}
@@ -0,0 +1,62 @@
+package agenda
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// ParallelRunner collects independent test directories to be run
+// in parallel (see Parallel())
+type ParallelRunner struct {
+	t    *testing.T
+	runs []parallelRun
+}
+
+// parallelRun is a single Run invocation added to the runner
+type parallelRun struct {
+	dir     string
+	test    Test
+	options []option
+}
+
+// Parallel returns the runner which runs several independent test directories
+// in parallel, reducing the wall-clock time for packages with many test
+// corpora. Each directory added with Add() is run as a parallel subtest
+// named after the directory once Wait() is called, and all the outcome
+// is reported to `t`.
+//
+// Example:
+//
+//		p := agenda.Parallel(t)
+//		p.Add("./testdata/parser", parseFunc)
+//		p.Add("./testdata/printer", printFunc, agenda.ResultSuffix(".out"))
+//		p.Wait()
+func Parallel(t *testing.T) *ParallelRunner {
+	return &ParallelRunner{t: t}
+}
+
+// Add schedules the test to be run against the directory, with the same
+// semantics as Run(). Options are not shared between the directories.
+func (p *ParallelRunner) Add(dir string, test Test, options ...option) {
+	if test == nil {
+		panic("test function is nil")
+	}
+	p.runs = append(p.runs, parallelRun{dir: dir, test: test, options: options})
+}
+
+// Wait runs all the added directories in parallel, waits for them
+// to complete and returns true if all of them succeeded
+func (p *ParallelRunner) Wait() bool {
+	runs := p.runs
+	p.runs = nil
+
+	return p.t.Run("parallel", func(t *testing.T) {
+		for _, r := range runs {
+			r := r
+			t.Run(filepath.ToSlash(filepath.Clean(r.dir)), func(t *testing.T) {
+				t.Parallel()
+				Run(t, r.dir, r.test, r.options...)
+			})
+		}
+	})
+}
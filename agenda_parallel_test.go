@@ -0,0 +1,15 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// TestParallel runs agenda tests against several directories in parallel
+func TestParallel(t *testing.T) {
+	p := Parallel(t)
+	p.Add("testdata/01/default", test01)
+	p.Add("testdata/01/custom-file-result-suffix", test01, FileSuffix(".in"), ResultSuffix(".out"))
+	if !p.Wait() {
+		t.Error("Expected all the directories to succeed")
+	}
+}
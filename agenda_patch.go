@@ -0,0 +1,475 @@
+package agenda
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// patchContext is the number of context lines in the patch hunks
+const patchContext = 3
+
+// patchSet collects the changes of the result files (see Patch())
+type patchSet struct {
+	mu    sync.Mutex
+	files map[string]patchFile
+}
+
+// patchFile holds the reference data and the generated output of a result file
+type patchFile struct {
+	reference []byte
+	output    []byte
+	created   bool
+}
+
+// Patch enables writing a unified patch file (valid for `git apply`) with
+// the changes of all result files whose reference data doesn't match
+// the generated output, or which don't exist yet, so that a reviewer can
+// inspect and apply the whole golden update as a single artifact.
+// File paths in the patch are relative to the root of the Git repository
+// (or to the current directory outside of one). The patch file is removed
+// if all results match.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Patch("/tmp/snapshots.patch"))
+func Patch(path string) option {
+	return func(o *optionSet) {
+		o.patchPath = path
+		o.patch = &patchSet{files: make(map[string]patchFile)}
+	}
+}
+
+// record is an internal function that records the change of the result file
+func (p *patchSet) record(resultPath string, reference, output []byte, created bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files[resultPath] = patchFile{reference, output, created}
+}
+
+// merge is an internal function that records the changes
+// collected in another patch set
+func (p *patchSet) merge(other *patchSet) {
+	other.mu.Lock()
+	defer other.mu.Unlock()
+	for resultPath, f := range other.files {
+		p.record(resultPath, f.reference, f.output, f.created)
+	}
+}
+
+// writePatch is an internal function that writes the patch file
+// with the recorded changes (see Patch())
+func writePatch(t *testing.T, opt *optionSet) {
+	opt.patch.mu.Lock()
+	defer opt.patch.mu.Unlock()
+
+	if len(opt.patch.files) == 0 {
+		if err := os.Remove(opt.patchPath); err != nil && !os.IsNotExist(err) {
+			t.Errorf("Can't remove the stale patch file: %v", err)
+		}
+		return
+	}
+
+	root := repoRoot()
+	paths := make([]string, 0, len(opt.patch.files))
+	for path := range opt.patch.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		f := opt.patch.files[path]
+		name := path
+		if abs, err := filepath.Abs(path); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil {
+				name = rel
+			}
+		}
+		b.WriteString(unifiedPatch(filepath.ToSlash(name), f.reference, f.output, f.created))
+	}
+
+	if err := ioutil.WriteFile(opt.patchPath, []byte(b.String()), 0644); err != nil {
+		t.Errorf("Can't save the patch file: %v", err)
+		return
+	}
+	logf(t, opt, logAlways, "The changes of %d result files were saved to '%s'", len(paths), opt.patchPath)
+}
+
+// repoRoot is an internal function that returns the root directory
+// of the Git repository containing the current directory,
+// or the current directory outside of one
+func repoRoot() string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	for dir := cwd; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if dir == filepath.Dir(dir) {
+			return cwd
+		}
+	}
+}
+
+// unifiedPatch is an internal function that renders the Git-style patch
+// of the file, with a single hunk spanning from the first to the last
+// changed line
+func unifiedPatch(name string, reference, output []byte, created bool) string {
+	a, b := patchLines(reference), patchLines(output)
+
+	// trim the common prefix and suffix, keeping the context lines
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	start := max(prefix-patchContext, 0)
+	endA := min(len(a)-suffix+patchContext, len(a))
+	endB := min(len(b)-suffix+patchContext, len(b))
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "diff --git a/%s b/%s\n", name, name)
+	if created {
+		fmt.Fprintf(&s, "new file mode 100644\n--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&s, "--- a/%s\n", name)
+	}
+	fmt.Fprintf(&s, "+++ b/%s\n", name)
+	fmt.Fprintf(&s, "@@ -%s +%s @@\n", hunkRange(start, endA-start), hunkRange(start, endB-start))
+	for _, line := range a[start:prefix] {
+		writePatchLine(&s, " ", line)
+	}
+	for _, line := range a[prefix : len(a)-suffix] {
+		writePatchLine(&s, "-", line)
+	}
+	for _, line := range b[prefix : len(b)-suffix] {
+		writePatchLine(&s, "+", line)
+	}
+	for _, line := range a[len(a)-suffix : endA] {
+		writePatchLine(&s, " ", line)
+	}
+	return s.String()
+}
+
+// patchLines is an internal function that splits the data into lines,
+// keeping the line endings
+func patchLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// hunkRange is an internal function that renders the range of the hunk
+// starting at the zero-based line `start`
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// writePatchLine is an internal function that writes the line of the hunk,
+// marking the line without the trailing newline
+func writePatchLine(s *strings.Builder, prefix, line string) {
+	s.WriteString(prefix + line)
+	if !strings.HasSuffix(line, "\n") {
+		s.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// filePatch is a parsed patch of a single file
+type filePatch struct {
+	name    string
+	created bool
+	hunks   []hunk
+}
+
+// hunk is a parsed hunk of the patch; lines keep their line endings
+type hunk struct {
+	oldStart int // one-based
+	oldLines []string
+	newLines []string
+}
+
+// ApplyPatch applies the patch file exported with the Patch() option
+// (or any other unified patch of text files), enabling a review-then-accept
+// workflow across machines: the patch can be produced on CI, reviewed,
+// and then applied locally. File paths in the patch are relative to the root
+// of the Git repository (or to the current directory outside of one).
+// The files are written the same way as in initialization mode, so options
+// like Output(), ConfineWrites() and FileMode() apply. Nothing is written
+// unless all hunks apply cleanly and all files can be written; if a write
+// fails nevertheless, the files written before it are restored.
+//
+// Example:
+//
+// err := agenda.ApplyPatch("/tmp/snapshots.patch")
+func ApplyPatch(path string, options ...option) error {
+	opt := newOptionSet(options)
+	root := repoRoot()
+	opt.roots = append(opt.roots, root)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	patches, err := parsePatch(string(data))
+	if err != nil {
+		return err
+	}
+
+	originals := make(map[string][]byte)
+	results := make(map[string][]byte)
+	for _, p := range patches {
+		name := filepath.Join(root, filepath.FromSlash(p.name))
+		var current []byte
+		if !p.created {
+			if current, err = readFile(name, opt); err != nil {
+				return err
+			}
+		} else if _, err := os.Stat(name); err == nil {
+			return fmt.Errorf("can't create '%s': file already exists", p.name)
+		}
+		if results[name], err = applyHunks(current, p.hunks); err != nil {
+			return fmt.Errorf("can't patch '%s': %v", p.name, err)
+		}
+		originals[name] = current
+	}
+
+	for _, p := range patches {
+		name := filepath.Join(root, filepath.FromSlash(p.name))
+		if err := checkWritable(name, opt); err != nil {
+			return fmt.Errorf("can't write '%s': %v", p.name, err)
+		}
+	}
+
+	var written []*filePatch
+	for _, p := range patches {
+		name := filepath.Join(root, filepath.FromSlash(p.name))
+		if err := writeFile(name, results[name], opt); err != nil {
+			restorePatched(root, written, originals, opt)
+			return err
+		}
+		written = append(written, p)
+	}
+	return nil
+}
+
+// checkWritable is an internal function that returns an error
+// if the file can't be written according to the options. Existing
+// read-only files are writable if their permissions can be changed
+// (see FileMode()).
+func checkWritable(name string, opt *optionSet) error {
+	if opt.confine {
+		if err := checkConfined(name, opt.roots); err != nil {
+			return err
+		}
+	}
+	if _, ok := opt.fs.(osFS); !ok {
+		return nil
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("'%s' is a directory", name)
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err == nil {
+		return f.Close()
+	}
+	if fi.Mode().Perm()&0200 == 0 {
+		// only the owner can make the file writable;
+		// changing the permissions to the same ones tells
+		return os.Chmod(name, fi.Mode().Perm())
+	}
+	return err
+}
+
+// restorePatched is an internal function that restores the files written
+// by ApplyPatch() to their original contents, removing the created ones
+func restorePatched(root string, written []*filePatch, originals map[string][]byte, opt *optionSet) {
+	for _, p := range written {
+		name := filepath.Join(root, filepath.FromSlash(p.name))
+		if p.created {
+			removeFile(name, opt)
+		} else {
+			writeFile(name, originals[name], opt)
+		}
+	}
+}
+
+// parsePatch is an internal function that parses the unified patch
+func parsePatch(data string) ([]*filePatch, error) {
+	var patches []*filePatch
+	var p *filePatch
+	var h *hunk
+	var last byte            // type of the last hunk line
+	var oldLeft, newLeft int // numbers of hunk lines yet to be read
+
+	for i, line := range patchLines([]byte(data)) {
+		switch {
+		case h != nil && line != "" && (oldLeft > 0 || newLeft > 0 || line[0] == '\\'):
+			switch line[0] {
+			case ' ':
+				h.oldLines = append(h.oldLines, line[1:])
+				h.newLines = append(h.newLines, line[1:])
+				oldLeft, newLeft = oldLeft-1, newLeft-1
+			case '-':
+				h.oldLines = append(h.oldLines, line[1:])
+				oldLeft--
+			case '+':
+				h.newLines = append(h.newLines, line[1:])
+				newLeft--
+			case '\\':
+				if last == 0 {
+					return nil, fmt.Errorf("malformed hunk line at line %d: %q", i+1, strings.TrimSpace(line))
+				}
+				if last == ' ' || last == '-' {
+					h.oldLines[len(h.oldLines)-1] = strings.TrimSuffix(h.oldLines[len(h.oldLines)-1], "\n")
+				}
+				if last == ' ' || last == '+' {
+					h.newLines[len(h.newLines)-1] = strings.TrimSuffix(h.newLines[len(h.newLines)-1], "\n")
+				}
+			default:
+				return nil, fmt.Errorf("malformed hunk line at line %d: %q", i+1, strings.TrimSpace(line))
+			}
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, fmt.Errorf("hunk at line %d is longer than its header says", i+1)
+			}
+			if line[0] != '\\' {
+				last = line[0]
+			}
+		case strings.HasPrefix(line, "diff --git "):
+			p, h = &filePatch{}, nil
+			patches = append(patches, p)
+		case strings.HasPrefix(line, "--- "):
+			if p == nil || h != nil {
+				p, h = &filePatch{}, nil
+				patches = append(patches, p)
+			}
+			p.created = strings.TrimSpace(line[4:]) == "/dev/null"
+		case strings.HasPrefix(line, "+++ ") && p != nil:
+			p.name = strings.TrimPrefix(strings.TrimSpace(line[4:]), "b/")
+		case strings.HasPrefix(line, "@@ ") && p != nil:
+			oldStart, newStart := 0, 0
+			if !parseHunkRange(line, " -", &oldStart, &oldLeft) || !parseHunkRange(line, " +", &newStart, &newLeft) {
+				return nil, fmt.Errorf("malformed hunk header at line %d: %q", i+1, strings.TrimSpace(line))
+			}
+			p.hunks = append(p.hunks, hunk{oldStart: oldStart})
+			h, last = &p.hunks[len(p.hunks)-1], 0
+		default:
+			h = nil
+		}
+	}
+
+	for _, p := range patches {
+		if p.name == "" {
+			return nil, fmt.Errorf("malformed patch: missing file name")
+		}
+		if p.name == "/dev/null" {
+			return nil, fmt.Errorf("deleting files is not supported")
+		}
+		if escapesRoot(p.name) {
+			return nil, fmt.Errorf("file '%s' is outside the repository", p.name)
+		}
+	}
+	return patches, nil
+}
+
+// parseHunkRange is an internal function that parses the range
+// ("-start,count" or "+start,count") of the hunk header
+func parseHunkRange(header, marker string, start, count *int) bool {
+	i := strings.Index(header, marker)
+	if i < 0 {
+		return false
+	}
+	fields := strings.Fields(header[i+len(marker):])
+	if len(fields) == 0 {
+		return false
+	}
+	r := fields[0]
+	*count = 1
+	if j := strings.IndexByte(r, ','); j >= 0 {
+		n, err := strconv.Atoi(r[j+1:])
+		if err != nil || n < 0 {
+			return false
+		}
+		*count, r = n, r[:j]
+	}
+	n, err := strconv.Atoi(r)
+	*start = n
+	return err == nil && n >= 0
+}
+
+// escapesRoot is an internal function that returns true
+// if the slash-separated path of the patched file is absolute
+// or points outside of the root directory
+func escapesRoot(name string) bool {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" || strings.HasPrefix(name, string(filepath.Separator)) {
+		return true
+	}
+	name = filepath.Clean(name)
+	return name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator))
+}
+
+// applyHunks is an internal function that applies the hunks to the data,
+// checking that the context and the removed lines match
+func applyHunks(data []byte, hunks []hunk) ([]byte, error) {
+	lines := patchLines(data)
+
+	var out []string
+	pos := 0
+	for _, h := range hunks {
+		start := h.oldStart - 1
+		if len(h.oldLines) == 0 {
+			start = h.oldStart // inserting after the line
+		}
+		if start < pos || start+len(h.oldLines) > len(lines) {
+			return nil, fmt.Errorf("hunk at line %d is out of range", h.oldStart)
+		}
+		for i, line := range h.oldLines {
+			if lines[start+i] != line {
+				return nil, fmt.Errorf("hunk at line %d doesn't match the file contents", h.oldStart)
+			}
+		}
+		out = append(out, lines[pos:start]...)
+		out = append(out, h.newLines...)
+		pos = start + len(h.oldLines)
+	}
+	out = append(out, lines[pos:]...)
+	return []byte(strings.Join(out, "")), nil
+}
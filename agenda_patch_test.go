@@ -0,0 +1,196 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestUnifiedPatch is a traditional (non agenda-based) test
+// that checks the rendering of the patches
+func TestUnifiedPatch(t *testing.T) {
+	var tests = []struct {
+		reference string
+		output    string
+		created   bool
+		want      string
+	}{
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n", "1\n2\n3\n4\nfive\n6\n7\n8\n9\n", false,
+			"diff --git a/x/1.result b/x/1.result\n--- a/x/1.result\n+++ b/x/1.result\n" +
+				"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"", "a\nb\n", true,
+			"diff --git a/x/1.result b/x/1.result\nnew file mode 100644\n--- /dev/null\n+++ b/x/1.result\n" +
+				"@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			"a\nb", "a\nb\nc", false,
+			"diff --git a/x/1.result b/x/1.result\n--- a/x/1.result\n+++ b/x/1.result\n" +
+				"@@ -1,2 +1,3 @@\n a\n-b\n\\ No newline at end of file\n+b\n+c\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, test := range tests {
+		got := unifiedPatch("x/1.result", []byte(test.reference), []byte(test.output), test.created)
+		if got != test.want {
+			t.Errorf("Expected:\n%s\ngot:\n%s", test.want, got)
+		}
+	}
+}
+
+// TestPatch is a traditional (non agenda-based) test that checks
+// that the patch file lists the changes of the failing cases only
+func TestPatch(t *testing.T) {
+	// the patched files have to be inside the repository
+	dir := copyDirIn(t, "testdata", "testdata/01/default")
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "2.json.result"), []byte("stale\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "3.json.result")); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "changes.patch")
+	opt := newOptionSet([]option{Patch(path)})
+	cases, err := listCases(dir, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		processFile(&fakeReporter{}, c, test01, opt)
+	}
+	writePatch(t, opt)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch := string(data)
+	if strings.Count(patch, "diff --git ") != 2 ||
+		!strings.Contains(patch, "2.json.result\n@@ -1 +1 @@\n-stale\n+{") ||
+		!strings.Contains(patch, "3.json.result\nnew file mode 100644\n") {
+		t.Errorf("Unexpected patch:\n%s", patch)
+	}
+
+	// applying the patch makes all cases pass
+	if err := ApplyPatch(path); err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeReporter{}
+	for _, c := range cases {
+		processFile(r, c, test01, newOptionSet(nil))
+	}
+	if len(r.errors) != 0 {
+		t.Errorf("Expected all cases to pass after applying the patch, got %v", r.errors)
+	}
+
+	// the patch doesn't apply twice, and nothing is written
+	if err := ioutil.WriteFile(filepath.Join(dir, "3.json.result"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyPatch(path); err == nil {
+		t.Errorf("Expected the patch not to apply twice")
+	}
+}
+
+// TestApplyPatchOptions is a traditional (non agenda-based) test that
+// checks that the patched files are written according to the options
+func TestApplyPatchOptions(t *testing.T) {
+	// the patched files have to be inside the repository
+	dir, err := ioutil.TempDir("testdata", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(repoRoot(), abs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.ToSlash(filepath.Join(rel, "1.result"))
+	path := filepath.Join(dir, "changes.patch")
+	patch := unifiedPatch(name, []byte("old\n"), []byte("new\n"), false)
+	if err := ioutil.WriteFile(path, []byte(patch), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resultPath := filepath.Join(dir, "1.result")
+	if err := ioutil.WriteFile(resultPath, []byte("old\n"), 0444); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := NewMemFS()
+	if err := ApplyPatch(path, Output(fsys)); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := fsys.ReadFile(filepath.Join(abs, "1.result")); err != nil || string(data) != "new\n" {
+		t.Errorf("Expected the patched file in the output file system, got %q (%v)", data, err)
+	}
+	if data, _ := ioutil.ReadFile(resultPath); string(data) != "old\n" {
+		t.Errorf("Expected the file on disk to stay intact, got %q", data)
+	}
+
+	// read-only result files can be patched
+	if err := ApplyPatch(path, FileMode(0444)); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(resultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(resultPath); string(data) != "new\n" || fi.Mode().Perm() != 0444 {
+		t.Errorf("Expected the read-only file to be patched, got %q (%v)", data, fi.Mode())
+	}
+}
+
+// TestApplyHunks is a traditional (non agenda-based) test
+// that checks applying the parsed hunks
+func TestApplyHunks(t *testing.T) {
+	patches, err := parsePatch("--- a/x\n+++ b/x\n@@ -1,2 +1,2 @@\n-a\n+A\n b\n@@ -4,0 +5 @@\n+e\n\\ No newline at end of file\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 1 || patches[0].name != "x" || len(patches[0].hunks) != 2 {
+		t.Fatalf("Unexpected patches: %+v", patches)
+	}
+
+	got, err := applyHunks([]byte("a\nb\nc\nd\n"), patches[0].hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "A\nb\nc\nd\ne" {
+		t.Errorf("Unexpected result: %q", got)
+	}
+
+	if _, err := applyHunks([]byte("x\nb\nc\nd\n"), patches[0].hunks); err == nil {
+		t.Errorf("Expected an error for the mismatching context")
+	}
+}
+
+// TestParseMalformedPatch is a traditional (non agenda-based) test
+// that checks that malformed patches are rejected without panicking
+func TestParseMalformedPatch(t *testing.T) {
+	for _, patch := range []string{
+		"--- a/x\n+++ b/x\n@@ -1 +\n",
+		"--- a/x\n+++ b/x\n@@ -1 @@\n",
+		"--- a/x\n+++ b/x\n@@ -1,-1 +1 @@\n",
+		"--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n+b\n@@ -3 +3 @@\n\\ No newline at end of file\n",
+		"--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n-b\n+c\n",
+		"--- a/../x\n+++ b/../x\n@@ -1 +1 @@\n-a\n+b\n",
+		"--- a/x\n+++ b/x/../../y\n@@ -1 +1 @@\n-a\n+b\n",
+		"--- /dev/null\n+++ /etc/x\n@@ -0,0 +1 @@\n+a\n",
+	} {
+		if _, err := parsePatch(patch); err == nil {
+			t.Errorf("Expected an error for the patch %q", patch)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package agenda
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Progress enables logging of the progress (the number of processed
+// cases and the estimated time remaining) every `every` cases and/or
+// every `interval`, so that long runs over large corpora are visibly
+// alive. Either of the values can be zero to disable the trigger.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Progress(1000, 30*time.Second))
+func Progress(every int, interval time.Duration) option {
+	return func(o *optionSet) {
+		o.progressEvery = every
+		o.progressInterval = interval
+	}
+}
+
+// progress is an internal structure that keeps track
+// of the number of processed cases
+type progress struct {
+	t        *testing.T
+	opt      *optionSet
+	total    int
+	done     int
+	every    int
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+}
+
+// newProgress is an internal function that returns the progress tracker
+// for the run, or nil if the progress reporting is disabled
+func newProgress(t *testing.T, total int, opt *optionSet) *progress {
+	if opt.progressEvery <= 0 && opt.progressInterval <= 0 {
+		return nil
+	}
+	now := time.Now()
+	return &progress{
+		t:        t,
+		opt:      opt,
+		total:    total,
+		every:    opt.progressEvery,
+		interval: opt.progressInterval,
+		start:    now,
+		last:     now,
+	}
+}
+
+// step records a processed case and logs the progress when due
+func (p *progress) step() {
+	p.done++
+	now := time.Now()
+	if (p.every > 0 && p.done%p.every == 0) ||
+		(p.interval > 0 && now.Sub(p.last) >= p.interval) {
+		p.last = now
+		logf(p.t, p.opt, logAlways, "%s", p.message(now))
+	}
+}
+
+// message renders the progress as of `now`
+func (p *progress) message(now time.Time) string {
+	msg := fmt.Sprintf("Processed %d/%d cases (%d%%)", p.done, p.total, p.done*100/p.total)
+	if p.done < p.total {
+		elapsed := now.Sub(p.start)
+		eta := elapsed / time.Duration(p.done) * time.Duration(p.total-p.done)
+		msg += fmt.Sprintf(", ETA %v", eta.Round(time.Second))
+	}
+	return msg
+}
@@ -0,0 +1,29 @@
+package agenda
+
+import (
+	"testing"
+	"time"
+)
+
+// TestProgressMessage is a traditional (non agenda-based) test
+// that checks the rendering of the progress
+func TestProgressMessage(t *testing.T) {
+	start := time.Now()
+	p := &progress{total: 400, done: 100, start: start}
+
+	want := "Processed 100/400 cases (25%), ETA 1m30s"
+	if got := p.message(start.Add(30 * time.Second)); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	p.done = 400
+	want = "Processed 400/400 cases (100%)"
+	if got := p.message(start.Add(2 * time.Minute)); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+// TestProgress runs agenda tests with the progress reporting enabled
+func TestProgress(t *testing.T) {
+	Run(t, "testdata/recursive", test01, Recursive(), Progress(2, 0))
+}
@@ -0,0 +1,106 @@
+package agenda
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// CaseProvider defines the function that generates test cases
+// programmatically instead of reading them from files. It calls `add`
+// for each case with its name and raw input data. Names can contain
+// slashes to group cases into nested subtests.
+type CaseProvider func(add func(name string, data []byte)) error
+
+// ResultDir allows you to specify the directory where result files
+// are stored. It is required by RunCases(), where the result file
+// of each case is named after the case name. With Run(), result files
+// are stored in a parallel directory tree mirroring the layout
+// of the test data directory; with RunDirs(), each test data directory
+// is mirrored in the subdirectory named after its base name.
+//
+// Example:
+//
+// // testdata/mytest/a/01.json -> testdata/golden/a/01.json.result
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Recursive(), agenda.ResultDir("testdata/golden"))
+//
+// agenda.RunCases(t, provider, testFunc, agenda.ResultDir("testdata/generated"))
+func ResultDir(dir string) option {
+	return func(o *optionSet) {
+		o.resultDir = dir
+	}
+}
+
+// resultRoot is an internal function that returns the directory where
+// the result files of the cases found in the test data directory are stored
+func resultRoot(dir string, opt *optionSet) string {
+	switch {
+	case opt.resultDir == "":
+		return dir
+	case opt.mirrorDirs:
+		return filepath.Join(opt.resultDir, filepath.Base(dir))
+	default:
+		return opt.resultDir
+	}
+}
+
+// RunCases executes an agenda test function (`test`) against all the cases
+// generated by the `provider`, so that generated or computed corpora
+// can use the snapshot machinery without materializing input files
+// on disk. The `path` argument passed to the test function is the case name.
+// The result file of each case is stored in the ResultDir() directory.
+//
+// Example:
+//
+//		agenda.RunCases(t, func(add func(name string, data []byte)) error {
+//			for i := 0; i < 10; i++ {
+//				add(fmt.Sprintf("case%02d", i), []byte(fmt.Sprintf(`{"a":%d,"b":%d}`, i, i*i)))
+//			}
+//			return nil
+//		}, testFunc, agenda.ResultDir("testdata/generated"))
+func RunCases(t *testing.T, provider CaseProvider, test Test, options ...option) {
+	if test == nil {
+		panic("test function is nil")
+	}
+	if provider == nil {
+		panic("case provider is nil")
+	}
+
+	opt := newOptionSet(options)
+	guardInit(t, opt)
+	if opt.resultDir == "" {
+		t.Fatalf("RunCases requires the ResultDir option")
+	}
+	opt.roots = append(opt.roots, opt.resultDir)
+
+	var cases []*testCase
+	err := provider(func(name string, data []byte) {
+		if opt.sanitize {
+			name = sanitizeName(name)
+		}
+		cases = append(cases, &testCase{
+			name:       name,
+			path:       name,
+			resultPath: filepath.Join(opt.resultDir, filepath.FromSlash(name)) + opt.resultSuffix,
+			data:       data,
+			provided:   true,
+		})
+	})
+	if err != nil {
+		t.Fatalf("Can't generate the cases: %v", err)
+	}
+
+	if len(cases) == 0 && !opt.initMode {
+		t.Fatalf("No cases generated")
+	}
+	if opt.expectCases {
+		if err := checkCaseCount(len(cases), opt); err != nil {
+			t.Fatalf("Unexpected number of cases: %v", err)
+		}
+	}
+	if len(opt.inputValidators) > 0 {
+		validateInputs(t, cases, opt)
+	}
+
+	cases = selectCases(t, cases, opt)
+	runCases(t, cases, test, opt)
+}
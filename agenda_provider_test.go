@@ -0,0 +1,32 @@
+package agenda
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestRunCases runs agenda tests against the cases
+// generated programmatically
+func TestRunCases(t *testing.T) {
+	RunCases(t, func(add func(name string, data []byte)) error {
+		for i := 1; i <= 3; i++ {
+			add(fmt.Sprintf("case%02d", i), []byte(fmt.Sprintf(`{"a":%d,"b":%d,"c":%d}`, i, i*2, i*3)))
+		}
+		add("zero/b", []byte(`{"a":1,"b":0,"c":1}`))
+		add("zero/c", []byte(`{"a":1,"b":1,"c":0}`))
+		return nil
+	}, test01, ResultDir("testdata/provider"))
+}
+
+// TestResultDir runs agenda tests with result files stored
+// in a separate directory tree mirroring the test data directory
+func TestResultDir(t *testing.T) {
+	Run(t, "testdata/result-dir/input", test01, Recursive(), ResultDir("testdata/result-dir/golden"))
+}
+
+// TestResultDirMultiple runs agenda tests against several directories
+// with result files stored in the subdirectories of the result directory
+func TestResultDirMultiple(t *testing.T) {
+	RunDirs(t, []string{"testdata/result-dir/input/a", "testdata/result-dir/input/b"}, test01,
+		ResultDir("testdata/result-dir/golden-dirs"))
+}
@@ -0,0 +1,126 @@
+package agenda
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// registry keeps the absolute paths of all directories the tests
+// were run against or read result files from in the current test binary;
+// the value is true if the subdirectories are used as well
+var registry = struct {
+	sync.Mutex
+	dirs map[string]bool
+}{dirs: make(map[string]bool)}
+
+// registerDir is an internal function that records the directory
+// as used by the tests (see CheckUnusedDirs())
+func registerDir(dir string) {
+	register(dir, false)
+}
+
+// registerTree is an internal function that records the directory
+// along with its subdirectories as used by the tests
+func registerTree(dir string) {
+	register(dir, true)
+}
+
+func register(dir string, tree bool) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.dirs[abs] = registry.dirs[abs] || tree
+}
+
+// CheckUnusedDirs runs the tests and then reports the subdirectories
+// of `root` that no agenda test was run against or read result files
+// from (e.g. with ResultDir() or Compare()), so that the dead
+// test corpora get noticed and cleaned up. It returns the exit code
+// for os.Exit(), which is non-zero if the tests failed or unused
+// directories were found. The check is skipped if the tests were
+// filtered with `go test -run`. The directories containing a used one
+// are not reported, nor are the subdirectories of the directories run
+// with Recursive() and of the golden directories of RunTree(), and
+// the directories of the included files (see Includes()).
+//
+// Example:
+//
+//		func TestMain(m *testing.M) {
+//			os.Exit(agenda.CheckUnusedDirs(m, "./testdata"))
+//		}
+func CheckUnusedDirs(m *testing.M, root string) int {
+	code := m.Run()
+	if code != 0 {
+		return code
+	}
+	if f := flag.Lookup("test.run"); f != nil && f.Value.String() != "" {
+		return code
+	}
+
+	unused, err := UnusedDirs(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "agenda: can't check for unused directories: %v\n", err)
+		return 1
+	}
+	for _, dir := range unused {
+		fmt.Fprintf(os.Stderr, "agenda: directory '%s' is not used by any test\n", dir)
+	}
+	if len(unused) > 0 {
+		return 1
+	}
+	return code
+}
+
+// UnusedDirs returns the sorted list of subdirectories of `root` that
+// no agenda test has been run against so far (see CheckUnusedDirs()).
+// Only the topmost unused directory of each unused tree is listed.
+func UnusedDirs(root string) ([]string, error) {
+	registry.Lock()
+	used := make(map[string]bool, len(registry.dirs))
+	for dir, tree := range registry.dirs {
+		used[dir] = tree
+	}
+	registry.Unlock()
+
+	var unused []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || path == root {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		for dir, tree := range used {
+			if abs == dir || within(dir, abs) || (tree && within(abs, dir)) {
+				return nil
+			}
+		}
+		unused = append(unused, path)
+		return filepath.SkipDir
+	})
+	sort.Strings(unused)
+	return unused, err
+}
+
+// within is an internal function that returns true
+// if the path is located inside the directory
+func within(path, dir string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
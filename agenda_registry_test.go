@@ -0,0 +1,76 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestUnusedDirs is a traditional (non agenda-based) test that checks
+// that only the directories not referenced by any test are reported
+func TestUnusedDirs(t *testing.T) {
+	root, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, dir := range []string{"a/nested", "b/c", "d/e", "f", "g/h", ".git"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registerDir(filepath.Join(root, "a"))
+	registerDir(filepath.Join(root, "d", "e"))
+	registerTree(filepath.Join(root, "g"))
+
+	unused, err := UnusedDirs(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{filepath.Join(root, "a", "nested"), filepath.Join(root, "b"), filepath.Join(root, "f")}
+	if !reflect.DeepEqual(unused, expected) {
+		t.Errorf("Expected %v, got %v", expected, unused)
+	}
+}
+
+// TestUnusedResultDirs is a traditional (non agenda-based) test that checks
+// that the directories result files are read from are considered used
+func TestUnusedResultDirs(t *testing.T) {
+	root, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	input := copyDirIn(t, root, "testdata/01/default")
+	golden := filepath.Join(root, "golden")
+	if err := os.Mkdir(golden, 0755); err != nil {
+		t.Fatal(err)
+	}
+	Run(t, input, test01, ResultDir(golden), InitMode(true))
+	Run(t, input, test01, ResultDir(golden))
+
+	compared := filepath.Join(root, "compared", "x.result")
+	Compare(t, compared, []byte("x"), InitMode(true))
+	Compare(t, compared, []byte("x"))
+
+	// the golden file in the root doesn't make its subdirectories used
+	dead := filepath.Join(root, "dead")
+	if err := os.Mkdir(dead, 0755); err != nil {
+		t.Fatal(err)
+	}
+	Compare(t, filepath.Join(root, "golden.txt"), []byte("x"), InitMode(true))
+	Compare(t, filepath.Join(root, "golden.txt"), []byte("x"))
+
+	unused, err := UnusedDirs(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(unused, []string{dead}) {
+		t.Errorf("Expected only %v to be unused, got %v", dead, unused)
+	}
+}
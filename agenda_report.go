@@ -0,0 +1,96 @@
+package agenda
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// reporter is the subset of *testing.T methods
+// used to report the outcome of a single case
+type reporter interface {
+	Log(args ...interface{})
+	Logf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// caseT is an internal structure that wraps the subtest of a case
+// and keeps track of the failures reported for it
+type caseT struct {
+	*testing.T
+	c        *testCase
+	opt      *optionSet
+	failures []string
+}
+
+func (t *caseT) Errorf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	t.failures = append(t.failures, msg)
+
+	if t.opt.shadow {
+		logf(t.T, t.opt, logAlways, "Shadow mode, ignoring the failure: %s", msg)
+		if t.opt.shadowSink != nil {
+			t.opt.shadowSink(t.c.path, msg)
+		}
+		return
+	}
+	t.T.Error(msg)
+}
+
+// failed returns true if any failures were reported for the case
+func (t *caseT) failed() bool {
+	return len(t.failures) > 0 || t.T.Failed()
+}
+
+// ShadowSink defines the callback function that receives the failures
+// of cases run in shadow mode: the path of the case and the failure message
+type ShadowSink func(path string, message string)
+
+// Shadow enables the "shadow" mode intended for long-running integration
+// environments: cases are executed and compared as usual, but failures
+// are only logged and passed to the `sink` (which can be nil), and never
+// fail the test. This gives visibility into the drift before the corpus
+// is enforced.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Shadow(agenda.ShadowReport(os.Stderr)))
+func Shadow(sink ShadowSink) option {
+	return func(o *optionSet) {
+		o.shadow = true
+		o.shadowSink = sink
+	}
+}
+
+// ShadowReport returns the ShadowSink which writes each failure
+// as a line of JSON (with "path" and "message" fields) to `w`.
+// It is safe for concurrent use.
+func ShadowReport(w io.Writer) ShadowSink {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(path string, message string) {
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(struct {
+			Path    string `json:"path"`
+			Message string `json:"message"`
+		}{path, message})
+	}
+}
+
+// reportShadow is an internal function that logs the summary
+// of the cases run in shadow mode
+func reportShadow(t *testing.T, cases []*testCase, opt *optionSet) {
+	total, failed := 0, 0
+	for _, c := range cases {
+		if c.ran {
+			total++
+			if c.failed {
+				failed++
+			}
+		}
+	}
+	logf(t, opt, logAlways, "Shadow mode: %d out of %d cases failed", failed, total)
+}
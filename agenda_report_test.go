@@ -0,0 +1,83 @@
+package agenda
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestShadow is a traditional (non agenda-based) test
+// that runs cases with outdated and missing result files in shadow mode
+// and verifies that failures are reported to the sink without failing the test
+func TestShadow(t *testing.T) {
+	var buf bytes.Buffer
+	Run(t, "testdata/shadow", test01, Shadow(ShadowReport(&buf)))
+
+	type failure struct {
+		Path    string `json:"path"`
+		Message string `json:"message"`
+	}
+
+	var failures []failure
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var f failure
+		if err := dec.Decode(&f); err != nil {
+			t.Fatal(err)
+		}
+		failures = append(failures, f)
+	}
+
+	if len(failures) != 2 {
+		t.Fatalf("Expected 2 failures, got %d: %+v", len(failures), failures)
+	}
+	if !strings.HasSuffix(failures[0].Path, "2.json") || !strings.Contains(failures[0].Message, "don't match") {
+		t.Errorf("Unexpected failure: %+v", failures[0])
+	}
+	if !strings.HasSuffix(failures[1].Path, "3.json") || !strings.Contains(failures[1].Message, "doesn't exist") {
+		t.Errorf("Unexpected failure: %+v", failures[1])
+	}
+}
+
+// fakeReporter collects the messages reported for a case
+type fakeReporter struct {
+	logs   []string
+	errors []string
+}
+
+func (r *fakeReporter) Log(args ...interface{}) {
+	r.logs = append(r.logs, fmt.Sprint(args...))
+}
+
+func (r *fakeReporter) Logf(format string, args ...interface{}) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func (r *fakeReporter) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// TestProcessFileErrors is a traditional (non agenda-based) test
+// that verifies that I/O errors are reported as failures of the case
+// instead of aborting the whole run
+func TestProcessFileErrors(t *testing.T) {
+	opt := newOptionSet(nil)
+	opt.initMode = false
+
+	var r fakeReporter
+	processFile(&r, &testCase{path: "testdata/missing.json", resultPath: "testdata/missing.json.result"}, test01, opt)
+	processFile(&r, &testCase{path: "testdata/shadow/3.json", resultPath: "testdata/shadow/3.json.result"}, test01, opt)
+	processFile(&r, &testCase{path: "testdata/shadow/1.json", resultPath: "testdata/shadow/1.json.result"}, test01, opt)
+
+	if len(r.errors) != 2 {
+		t.Fatalf("Expected 2 errors, got %d: %v", len(r.errors), r.errors)
+	}
+	if !strings.HasPrefix(r.errors[0], "Can't read the file") {
+		t.Errorf("Unexpected error: %s", r.errors[0])
+	}
+	if !strings.Contains(r.errors[1], "doesn't exist") {
+		t.Errorf("Unexpected error: %s", r.errors[1])
+	}
+}
@@ -0,0 +1,113 @@
+package agenda
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// CaseStatus describes the outcome of a single case
+type CaseStatus string
+
+// Possible outcomes of a case
+const (
+	StatusPassed      CaseStatus = "passed"
+	StatusFailed      CaseStatus = "failed"
+	StatusInitialized CaseStatus = "initialized"
+	StatusSkipped     CaseStatus = "skipped" // e.g. filtered out with `go test -run`
+)
+
+// Results describes the outcome of the run returned by RunWithResults()
+type Results struct {
+	Summary
+	Cases []CaseResult
+}
+
+// CaseResult describes the outcome of a single case
+type CaseResult struct {
+	Name       string // subtest name of the case
+	Path       string
+	ResultPath string
+	Status     CaseStatus
+	Duration   time.Duration // execution time of the test function
+	Failures   []string      // failure messages, including the diffs
+	Written    []string      // files written in initialization mode
+}
+
+// RunWithResults is similar to Run, but besides reporting the outcome
+// to the test, it returns the Results of the run for programmatic
+// post-processing (e.g. from TestMain or wrapper tools).
+//
+// Example:
+//
+//		res := agenda.RunWithResults(t, "./testdata/mytest", testFunc)
+//		for _, c := range res.Cases {
+//			fmt.Println(c.Path, c.Status, c.Duration)
+//		}
+func RunWithResults(t *testing.T, dir string, test Test, options ...option) Results {
+	if test == nil {
+		panic("test function is nil")
+	}
+
+	opt := newOptionSet(options)
+	opt.fs = &trackingFS{WritableFS: opt.fs}
+
+	start := time.Now()
+	cases := runDirs(t, []string{dir}, test, opt)
+
+	res := Results{Summary: summarize(cases, start, opt)}
+	for _, c := range cases {
+		res.Cases = append(res.Cases, CaseResult{
+			Name:       c.name,
+			Path:       c.path,
+			ResultPath: c.resultPath,
+			Status:     c.status(opt),
+			Duration:   c.duration,
+			Failures:   c.failures,
+			Written:    c.written,
+		})
+	}
+	return res
+}
+
+// status returns the outcome of the processed case
+func (c *testCase) status(opt *optionSet) CaseStatus {
+	switch {
+	case !c.ran:
+		return StatusSkipped
+	case c.failed:
+		return StatusFailed
+	case opt.initMode:
+		return StatusInitialized
+	default:
+		return StatusPassed
+	}
+}
+
+// trackingFS is a WritableFS which keeps track of the written files
+type trackingFS struct {
+	WritableFS
+	written []string
+}
+
+func (f *trackingFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	if err := f.WritableFS.WriteFile(name, data, perm); err != nil {
+		return err
+	}
+	f.written = append(f.written, name)
+	return nil
+}
+
+func (f *trackingFS) ReadFile(name string) ([]byte, error) {
+	if r, ok := f.WritableFS.(fileReader); ok {
+		return r.ReadFile(name)
+	}
+	return nil, os.ErrNotExist
+}
+
+func (f *trackingFS) Remove(name string) error {
+	if r, ok := f.WritableFS.(remover); ok {
+		return r.Remove(name)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// TestRunWithResults is a traditional (non agenda-based) test
+// that checks the results returned in test and initialization modes
+func TestRunWithResults(t *testing.T) {
+	res := RunWithResults(t, "testdata/recursive", test01, Recursive())
+	if res.Total != 4 || res.Passed != 4 {
+		t.Errorf("Unexpected summary: %+v", res.Summary)
+	}
+	if len(res.Cases) != 4 {
+		t.Fatalf("Expected 4 cases, got %d", len(res.Cases))
+	}
+	if c := res.Cases[2]; c.Name != "api/v2/3.json" || c.Status != StatusPassed ||
+		c.ResultPath != "testdata/recursive/api/v2/3.json.result" {
+		t.Errorf("Unexpected case result: %+v", c)
+	}
+
+	fsys := NewMemFS()
+	res = RunWithResults(t, "testdata/recursive", test01, Recursive(), InitMode(true), Output(fsys))
+	if res.Initialized != 4 {
+		t.Errorf("Unexpected summary: %+v", res.Summary)
+	}
+	for _, c := range res.Cases {
+		if len(c.Written) != 1 || c.Written[0] != c.ResultPath {
+			t.Errorf("Unexpected written files of %s: %v", c.Path, c.Written)
+		}
+	}
+
+	res = RunWithResults(t, "testdata/shadow", test01, Shadow(nil))
+	if res.Failed != 2 || res.Cases[2].Status != StatusFailed || len(res.Cases[2].Failures) != 1 {
+		t.Errorf("Unexpected results: %+v", res)
+	}
+}
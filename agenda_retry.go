@@ -0,0 +1,161 @@
+package agenda
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// Retry allows you to retry failed snapshot storage operations (reading
+// input and result files, and writing result files to the WritableFS,
+// which can be backed by remote storage) up to `attempts` times in total,
+// with exponential backoff starting with the `backoff` delay. Errors meaning
+// that the file doesn't exist are not retried. If all attempts fail,
+// the reported error lists the errors of every attempt. To re-run
+// the cases that fail, use RetryFailedCases() instead.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc,
+//     agenda.Output(remoteFS), agenda.Retry(5, 100*time.Millisecond))
+func Retry(attempts int, backoff time.Duration) option {
+	return func(o *optionSet) {
+		o.retries = attempts
+		o.retryBackoff = backoff
+	}
+}
+
+// retryError aggregates the errors of all failed attempts
+type retryError []error
+
+func (e retryError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = fmt.Sprintf("attempt %d: %v", i+1, err)
+	}
+	return fmt.Sprintf("failed after %d attempts (%s)", len(e), strings.Join(msgs, "; "))
+}
+
+// withRetry is an internal function that calls `f` until it succeeds,
+// retrying it according to the options
+func withRetry(opt *optionSet, f func() error) error {
+	var errs retryError
+	delay := opt.retryBackoff
+	for {
+		err := f()
+		if err == nil || os.IsNotExist(err) {
+			return err
+		}
+
+		errs = append(errs, err)
+		if len(errs) >= opt.retries {
+			if len(errs) == 1 {
+				return err
+			}
+			return errs
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// readFile is an internal function that reads the file,
+// retrying it according to the options
+func readFile(path string, opt *optionSet) ([]byte, error) {
+	var data []byte
+	err := withRetry(opt, func() (err error) {
+		data, err = ioutil.ReadFile(path)
+		return err
+	})
+	return data, err
+}
+
+// RetryFailedCases allows you to re-run a failing case up to `n` more
+// times before it is reported as failed. The failures of the attempts
+// that were retried are logged. This is meant as a stopgap for test
+// functions exercising timing-sensitive code; the retries are not
+// performed in initialization mode. Unlike Retry(), which retries
+// failed storage operations, it retries the whole case.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.RetryFailedCases(2))
+func RetryFailedCases(n int) option {
+	return func(o *optionSet) {
+		o.caseRetries = n
+	}
+}
+
+// caseLog is a reporter which buffers the messages of a single attempt
+type caseLog struct {
+	logs     []string
+	failures []string
+}
+
+func (l *caseLog) Log(args ...interface{}) {
+	l.logs = append(l.logs, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
+
+func (l *caseLog) Logf(format string, args ...interface{}) {
+	l.logs = append(l.logs, fmt.Sprintf(format, args...))
+}
+
+func (l *caseLog) Errorf(format string, args ...interface{}) {
+	l.failures = append(l.failures, fmt.Sprintf(format, args...))
+}
+
+// retryCase is an internal function that processes the case,
+// re-running it according to the options while it fails
+func retryCase(t reporter, c *testCase, test Test, opt *optionSet) {
+	if opt.caseRetries <= 0 || opt.initMode {
+		processFile(t, c, test, opt)
+		return
+	}
+
+	for attempt := 0; ; attempt++ {
+		l := &caseLog{}
+		aopt := attemptOptions(opt)
+		processFile(l, c, test, aopt)
+		if len(l.failures) > 0 && attempt < opt.caseRetries {
+			logf(t, opt, logAlways, "Attempt %d failed, retrying: %s", attempt+1, strings.Join(l.failures, "; "))
+			continue
+		}
+
+		// replay the outcome of the last attempt
+		if opt.patch != nil {
+			opt.patch.merge(aopt.patch)
+		}
+		if opt.githubOutput != nil {
+			opt.githubOutput.Write(aopt.githubOutput.(*bytes.Buffer).Bytes())
+		}
+		for _, s := range l.logs {
+			t.Log(s)
+		}
+		for _, s := range l.failures {
+			t.Errorf("%s", s)
+		}
+		if len(l.failures) == 0 && attempt > 0 {
+			logf(t, opt, logAlways, "Case passed after %d retries", attempt)
+		}
+		return
+	}
+}
+
+// attemptOptions is an internal function that returns the options
+// for a single attempt of the case, which collect the changes
+// of the result files and the GitHub annotations separately,
+// so that only the outcome of the last attempt is kept
+func attemptOptions(opt *optionSet) *optionSet {
+	o := *opt
+	if opt.patch != nil {
+		o.patch = &patchSet{files: make(map[string]patchFile)}
+	}
+	if opt.githubOutput != nil {
+		o.githubOutput = &bytes.Buffer{}
+	}
+	return &o
+}
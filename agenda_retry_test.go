@@ -0,0 +1,118 @@
+package agenda
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyFS is a WritableFS which fails the first `failures` writes
+type flakyFS struct {
+	*MemFS
+	failures int
+	attempts int
+}
+
+func (f *flakyFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	f.attempts++
+	if f.attempts <= f.failures {
+		return errors.New("503 Service Unavailable")
+	}
+	return f.MemFS.WriteFile(name, data, perm)
+}
+
+// TestRetry is a traditional (non agenda-based) test
+// that checks retrying of failed writes and the aggregated error
+func TestRetry(t *testing.T) {
+	fsys := &flakyFS{MemFS: NewMemFS(), failures: 2}
+	opt := newOptionSet([]option{Output(fsys), Retry(3, time.Millisecond)})
+
+	if err := writeFile("a.result", []byte("a"), opt); err != nil {
+		t.Errorf("Expected the write to succeed, got %v", err)
+	}
+	if fsys.attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", fsys.attempts)
+	}
+
+	fsys = &flakyFS{MemFS: NewMemFS(), failures: 5}
+	opt = newOptionSet([]option{Output(fsys), Retry(3, time.Millisecond)})
+
+	err := writeFile("a.result", []byte("a"), opt)
+	if err == nil || !strings.Contains(err.Error(), "failed after 3 attempts") {
+		t.Errorf("Expected an aggregated error, got %v", err)
+	}
+
+	if _, err := readFile("testdata/missing", opt); !os.IsNotExist(err) {
+		t.Errorf("Expected a 'not exist' error, got %v", err)
+	}
+}
+
+// TestRetryFailedCases is a traditional (non agenda-based) test
+// that checks re-running of failing cases
+func TestRetryFailedCases(t *testing.T) {
+	calls := 0
+	flaky := func(path string, data []byte) ([]byte, error) {
+		calls++
+		if calls <= 2 {
+			return []byte("unexpected"), nil
+		}
+		return test01(path, data)
+	}
+	c := &testCase{path: "testdata/shadow/1.json", resultPath: "testdata/shadow/1.json.result"}
+
+	opt := newOptionSet([]option{RetryFailedCases(2)})
+	opt.initMode = false
+
+	var r fakeReporter
+	retryCase(&r, c, flaky, opt)
+	if len(r.errors) != 0 {
+		t.Errorf("Expected the case to pass, got %v", r.errors)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+	if last := r.logs[len(r.logs)-1]; last != "Case passed after 2 retries" {
+		t.Errorf("Unexpected log message: %s", last)
+	}
+
+	calls = 0
+	opt = newOptionSet([]option{RetryFailedCases(1)})
+	opt.initMode = false
+
+	r = fakeReporter{}
+	retryCase(&r, c, flaky, opt)
+	if len(r.errors) != 1 {
+		t.Errorf("Expected 1 error, got %v", r.errors)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls)
+	}
+}
+
+// TestRetryFailedCasesPatch is a traditional (non agenda-based) test that checks
+// that the failures of the retried attempts don't end up in the patch
+func TestRetryFailedCasesPatch(t *testing.T) {
+	calls := 0
+	flaky := func(path string, data []byte) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			return []byte("flaky"), nil
+		}
+		return test01(path, data)
+	}
+	c := &testCase{path: "testdata/shadow/1.json", resultPath: "testdata/shadow/1.json.result"}
+
+	opt := newOptionSet([]option{RetryFailedCases(1), Patch("x.patch")})
+	opt.initMode = false
+
+	var r fakeReporter
+	retryCase(&r, c, flaky, opt)
+	if len(r.errors) != 0 {
+		t.Errorf("Expected the case to pass, got %v", r.errors)
+	}
+	if len(opt.patch.files) != 0 {
+		t.Errorf("Expected no changes in the patch, got %v", opt.patch.files)
+	}
+}
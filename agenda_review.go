@@ -0,0 +1,156 @@
+package agenda
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pendingSuffix is appended to the result file path to get the name
+// of the file with the generated output awaiting review
+const pendingSuffix = ".pending"
+
+// Pending enables saving the generated output of each failing case
+// next to its result file (e.g. "01.json.result.pending"), so that
+// the changes can be reviewed and accepted later with Review().
+// Pending files of the cases that pass are removed.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Pending())
+func Pending() option {
+	return func(o *optionSet) {
+		o.pending = true
+	}
+}
+
+// checkPending is an internal function that checks the result
+// and saves the generated output for review on mismatch (see Pending())
+func checkPending(t reporter, resultPath string, output []byte, opt *optionSet) {
+	fc := &failureCounter{reporter: t}
+	checkResult(fc, resultPath, output, opt)
+	if opt.initMode {
+		return
+	}
+
+	pendingPath := resultPath + pendingSuffix
+	if fc.failures == 0 {
+		if err := removeFile(pendingPath, opt); err != nil {
+			t.Errorf("Can't remove the '%s' file: %v", pendingPath, err)
+		}
+		return
+	}
+
+	if err := writeFile(pendingPath, output, opt); err != nil {
+		t.Errorf("Can't save the generated output: %v", err)
+		return
+	}
+	logf(t, opt, logAlways, "The generated output was saved to '%s' for review", pendingPath)
+}
+
+// Review goes through the pending changes in the directory (see Pending()),
+// shows the diff for each of them, and asks whether to accept the change,
+// reading the answers from `in` and writing the diffs and the prompts to `out`.
+// Accepted outputs become the new result files, and rejected ones
+// are discarded; skipped ones are kept for later review.
+//
+// Example:
+//
+//		// cmd/review/main.go
+//		func main() {
+//			if err := agenda.Review("./testdata/mytest", os.Stdin, os.Stdout); err != nil {
+//				log.Fatal(err)
+//			}
+//		}
+func Review(dir string, in io.Reader, out io.Writer, options ...option) error {
+	opt := newOptionSet(options)
+
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && !opt.recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, opt.resultSuffix+pendingSuffix) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	if len(paths) == 0 {
+		fmt.Fprintf(out, "No pending changes in %s\n", dir)
+		return nil
+	}
+
+	answers := bufio.NewScanner(in)
+	for i, pendingPath := range paths {
+		resultPath := strings.TrimSuffix(pendingPath, pendingSuffix)
+		output, err := readFile(pendingPath, opt)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "\n[%d/%d] %s\n", i+1, len(paths), resultPath)
+		reference, err := readFile(resultPath, opt)
+		switch {
+		case os.IsNotExist(err):
+			fmt.Fprintf(out, "New result file:\n%s\n", output)
+		case err != nil:
+			return err
+		default:
+			r := &printReporter{w: out}
+			compareOutput(r, resultPath, reference, output, opt)
+			if !r.failed {
+				fmt.Fprintf(out, "The result file is up to date\n")
+				if err := removeFile(pendingPath, opt); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		for answered := false; !answered; {
+			fmt.Fprintf(out, "Accept the change? [y]es, [n]o, [s]kip, [q]uit: ")
+			if !answers.Scan() {
+				fmt.Fprintln(out)
+				return answers.Err()
+			}
+
+			answered = true
+			switch strings.ToLower(strings.TrimSpace(answers.Text())) {
+			case "y", "yes":
+				if err := writeFile(resultPath, output, opt); err != nil {
+					return err
+				}
+				if err := removeFile(pendingPath, opt); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "Accepted\n")
+			case "n", "no":
+				if err := removeFile(pendingPath, opt); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "Rejected\n")
+			case "s", "skip":
+			case "q", "quit":
+				return nil
+			default:
+				answered = false
+			}
+		}
+	}
+	return nil
+}
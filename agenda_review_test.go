@@ -0,0 +1,60 @@
+package agenda
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReview is a traditional (non agenda-based) test that saves
+// the pending changes of the failing cases, and then accepts one
+// of them and rejects the other one
+func TestReview(t *testing.T) {
+	dir := copyDir(t, "testdata/01/default")
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"1.json.result", "2.json.result"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("stale\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opt := newOptionSet([]option{Pending()})
+	cases, err := listCases(dir, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeReporter{}
+	for _, c := range cases {
+		processFile(r, c, test01, opt)
+	}
+	if len(r.errors) != 2 {
+		t.Fatalf("Expected 2 failures, got %d", len(r.errors))
+	}
+
+	var out bytes.Buffer
+	if err := Review(dir, strings.NewReader("maybe\ny\nn\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "[2/2] "+filepath.Join(dir, "2.json.result")) ||
+		strings.Count(out.String(), "Accept the change?") != 3 {
+		t.Errorf("Unexpected review output:\n%s", out.String())
+	}
+
+	accepted, _ := ioutil.ReadFile(filepath.Join(dir, "1.json.result"))
+	reference, _ := ioutil.ReadFile("testdata/01/default/1.json.result")
+	if !bytes.Equal(accepted, reference) {
+		t.Errorf("Expected the accepted change to be written, got %q", accepted)
+	}
+	if rejected, _ := ioutil.ReadFile(filepath.Join(dir, "2.json.result")); string(rejected) != "stale\n" {
+		t.Errorf("Expected the rejected change not to be written, got %q", rejected)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+pendingSuffix))
+	if len(files) != 0 {
+		t.Errorf("Expected no pending files left, got %v", files)
+	}
+}
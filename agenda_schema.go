@@ -0,0 +1,358 @@
+package agenda
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// OutputSchema enables the validation of the generated output of each case
+// against the JSON Schema in the file at `path`, in addition to the comparison
+// with the reference data, so that violations of the structural contract
+// are reported separately from the changed values (also in initialization
+// mode). The schema of an individual case can be set with the "schema" field
+// of the "cases.json" file (see Tags()), relative to the test directory.
+//
+// A subset of JSON Schema is supported: "type", "enum", "const",
+// "properties", "required", "additionalProperties", "items",
+// "minItems", "maxItems", "minLength", "maxLength", "pattern",
+// "minimum", "maximum", "allOf", "anyOf", "oneOf", "not",
+// and local "$ref" references (e.g. "#/definitions/user").
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.OutputSchema("./testdata/response.schema.json"))
+func OutputSchema(path string) option {
+	return func(o *optionSet) {
+		o.schemaPath = path
+	}
+}
+
+// checkSchema is an internal function that validates the data against
+// the JSON Schema file, and returns the list of violations
+func checkSchema(schemaPath string, data []byte) ([]string, error) {
+	schemaData, err := ioutil.ReadFile(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := decodeJSON(schemaData)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse the schema '%s': %v", schemaPath, err)
+	}
+
+	v, err := decodeJSON(data)
+	if err != nil {
+		return []string{fmt.Sprintf("(root): not a valid JSON document: %v", err)}, nil
+	}
+
+	var violations []string
+	if err := validateSchema("", v, schema, schema, &violations); err != nil {
+		return nil, fmt.Errorf("can't use the schema '%s': %v", schemaPath, err)
+	}
+	return violations, nil
+}
+
+// validateSchema is an internal function that recursively validates
+// the decoded JSON value against the (sub)schema and appends a line
+// for each violation. It returns an error if the schema is malformed.
+func validateSchema(path string, v, schema, root interface{}, violations *[]string) error {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		if b, isBool := schema.(bool); isBool {
+			if !b {
+				addViolation(violations, path, "no value is allowed")
+			}
+			return nil
+		}
+		return fmt.Errorf("schema at %s is not an object", schemaPath(path))
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		resolved, err := resolveRef(ref, root)
+		if err != nil {
+			return err
+		}
+		return validateSchema(path, v, resolved, root, violations)
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(v, t) {
+		addViolation(violations, path, fmt.Sprintf("expected type %s, got %s", formatJSON(t), jsonType(v)))
+		return nil
+	}
+	if c, ok := s["const"]; ok && !equalJSON(v, c) {
+		addViolation(violations, path, fmt.Sprintf("expected %s, got %s", formatJSON(c), formatJSON(v)))
+	}
+	if e, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, ev := range e {
+			found = found || equalJSON(v, ev)
+		}
+		if !found {
+			addViolation(violations, path, fmt.Sprintf("%s is not one of %s", formatJSON(v), formatJSON(e)))
+		}
+	}
+
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, k := range required {
+				if name, ok := k.(string); ok {
+					if _, ok := x[name]; !ok {
+						addViolation(violations, path, fmt.Sprintf("missing required key %q", name))
+					}
+				}
+			}
+		}
+		properties, _ := s["properties"].(map[string]interface{})
+		for _, k := range sortedKeys(x) {
+			keyPath := jsonKeyPath(path, k)
+			if ps, ok := properties[k]; ok {
+				if err := validateSchema(keyPath, x[k], ps, root, violations); err != nil {
+					return err
+				}
+				continue
+			}
+			if ap, ok := s["additionalProperties"]; ok {
+				if b, ok := ap.(bool); ok && !b {
+					addViolation(violations, path, fmt.Sprintf("unexpected key %q", k))
+					continue
+				}
+				if err := validateSchema(keyPath, x[k], ap, root, violations); err != nil {
+					return err
+				}
+			}
+		}
+
+	case []interface{}:
+		checkBounds(violations, path, "items", len(x), s, "minItems", "maxItems")
+		if items, ok := s["items"]; ok {
+			for i, e := range x {
+				if err := validateSchema(fmt.Sprintf("%s[%d]", path, i), e, items, root, violations); err != nil {
+					return err
+				}
+			}
+		}
+
+	case string:
+		checkBounds(violations, path, "characters", utf8.RuneCountInString(x), s, "minLength", "maxLength")
+		if pattern, ok := s["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern at %s: %v", schemaPath(path), err)
+			}
+			if !re.MatchString(x) {
+				addViolation(violations, path, fmt.Sprintf("%q doesn't match the pattern %q", x, pattern))
+			}
+		}
+
+	case json.Number:
+		n, _ := x.Float64()
+		if min, ok := schemaNumber(s, "minimum"); ok && n < min {
+			addViolation(violations, path, fmt.Sprintf("%s is less than the minimum %v", x, min))
+		}
+		if max, ok := schemaNumber(s, "maximum"); ok && n > max {
+			addViolation(violations, path, fmt.Sprintf("%s is greater than the maximum %v", x, max))
+		}
+	}
+
+	return validateCombinators(path, v, s, root, violations)
+}
+
+// validateCombinators is an internal function that validates the value
+// against the "allOf", "anyOf", "oneOf" and "not" keywords of the schema
+func validateCombinators(path string, v interface{}, s map[string]interface{}, root interface{}, violations *[]string) error {
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if err := validateSchema(path, v, sub, root, violations); err != nil {
+				return err
+			}
+		}
+	}
+
+	countValid := func(schemas []interface{}) (int, error) {
+		n := 0
+		for _, sub := range schemas {
+			var subViolations []string
+			if err := validateSchema(path, v, sub, root, &subViolations); err != nil {
+				return 0, err
+			}
+			if len(subViolations) == 0 {
+				n++
+			}
+		}
+		return n, nil
+	}
+
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		n, err := countValid(anyOf)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			addViolation(violations, path, "value doesn't match any of the \"anyOf\" schemas")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		n, err := countValid(oneOf)
+		if err != nil {
+			return err
+		}
+		if n != 1 {
+			addViolation(violations, path, fmt.Sprintf("value matches %d of the \"oneOf\" schemas instead of one", n))
+		}
+	}
+	if not, ok := s["not"]; ok {
+		n, err := countValid([]interface{}{not})
+		if err != nil {
+			return err
+		}
+		if n != 0 {
+			addViolation(violations, path, "value matches the \"not\" schema")
+		}
+	}
+	return nil
+}
+
+// resolveRef is an internal function that resolves the local
+// reference (JSON pointer) within the root schema
+func resolveRef(ref string, root interface{}) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only local references are supported, got %q", ref)
+	}
+
+	v := root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("can't resolve the reference %q", ref)
+		}
+		if v, ok = m[token]; !ok {
+			return nil, fmt.Errorf("can't resolve the reference %q", ref)
+		}
+	}
+	return v, nil
+}
+
+// matchesType is an internal function that returns true if the value
+// is of the type (or one of the types) specified in the schema
+func matchesType(v interface{}, t interface{}) bool {
+	types, ok := t.([]interface{})
+	if !ok {
+		types = []interface{}{t}
+	}
+	actual := jsonType(v)
+	for _, expected := range types {
+		if expected == actual || expected == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType is an internal function that returns the JSON Schema
+// type name of the decoded JSON value
+func jsonType(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := x.Int64(); err == nil {
+			return "integer"
+		}
+		if f, err := x.Float64(); err == nil && f == float64(int64(f)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// equalJSON is an internal function that compares the decoded
+// JSON values, treating equal numbers in different notations as equal
+func equalJSON(a, b interface{}) bool {
+	var lines []string
+	diffJSON("", normalizeNumbers(a), normalizeNumbers(b), &lines)
+	return len(lines) == 0
+}
+
+// normalizeNumbers is an internal function that converts
+// all numbers of the decoded JSON value to float64
+func normalizeNumbers(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		f, _ := x.Float64()
+		return f
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, e := range x {
+			m[k] = normalizeNumbers(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(x))
+		for i, e := range x {
+			a[i] = normalizeNumbers(e)
+		}
+		return a
+	}
+	return v
+}
+
+// schemaNumber is an internal function that returns
+// the numeric keyword of the schema, if it is set
+func schemaNumber(s map[string]interface{}, keyword string) (float64, bool) {
+	n, ok := s[keyword].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+// checkBounds is an internal function that validates the length
+// of the value against the minimum and maximum keywords of the schema
+func checkBounds(violations *[]string, path, unit string, n int, s map[string]interface{}, minKeyword, maxKeyword string) {
+	if min, ok := schemaNumber(s, minKeyword); ok && float64(n) < min {
+		addViolation(violations, path, fmt.Sprintf("expected at least %v %s, got %d", min, unit, n))
+	}
+	if max, ok := schemaNumber(s, maxKeyword); ok && float64(n) > max {
+		addViolation(violations, path, fmt.Sprintf("expected at most %v %s, got %d", max, unit, n))
+	}
+}
+
+// sortedKeys is an internal function that returns the sorted keys of the object
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// addViolation is an internal function that appends
+// the violation message prefixed with the value path
+func addViolation(violations *[]string, path, message string) {
+	*violations = append(*violations, schemaPath(path)+": "+message)
+}
+
+// schemaPath is an internal function that returns
+// the printable path of the value
+func schemaPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
@@ -0,0 +1,65 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestOutputSchema runs agenda tests whose output is validated
+// against the JSON Schema of the directory and of the individual case
+func TestOutputSchema(t *testing.T) {
+	Run(t, "testdata/output-schema", test01, OutputSchema("testdata/output-schema/result.schema"), Strict())
+}
+
+// TestCheckSchema is a traditional (non agenda-based) test
+// that checks the reported schema violations
+func TestCheckSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	schema := `{
+		"type": "object",
+		"required": ["id", "tags"],
+		"properties": {
+			"id": {"type": "integer", "maximum": 10},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string", "pattern": "^[a-z]+$"}},
+			"kind": {"enum": ["a", "b"]},
+			"meta": {"oneOf": [{"type": "string"}, {"type": "null"}]}
+		},
+		"additionalProperties": false
+	}`
+	path := filepath.Join(dir, "test.schema")
+	if err := ioutil.WriteFile(path, []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	violations, err := checkSchema(path, []byte(`{"id": 5, "tags": ["x"], "kind": "a", "meta": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+
+	violations, err = checkSchema(path, []byte(`{"id": 12.5, "tags": ["x", "Y", "z"], "kind": "c", "meta": 1, "extra": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`(root): unexpected key "extra"`,
+		`id: expected type "integer", got number`,
+		`kind: "c" is not one of ["a","b"]`,
+		`meta: value matches 0 of the "oneOf" schemas instead of one`,
+		`tags: expected at most 2 items, got 3`,
+		`tags[1]: "Y" doesn't match the pattern "^[a-z]+$"`,
+	}
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("Unexpected violations:\n%q", violations)
+	}
+}
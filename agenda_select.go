@@ -0,0 +1,118 @@
+package agenda
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// Sample allows you to run only a random subset of the discovered
+// input data files, which is useful to get a quick signal from
+// a large corpus locally while CI runs the full set.
+// `fraction` is a number between 0 and 1; the subset is deterministic
+// for the same `seed` and the same set of files. If `seed` is 0,
+// a random seed is used. The seed is always logged, so that the same
+// sample can be reproduced.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Sample(0.1, 42))
+func Sample(fraction float64, seed int64) option {
+	return func(o *optionSet) {
+		o.sample = fraction
+		o.sampleSeed = seed
+	}
+}
+
+// Shard allows you to run only the cases of one of `total` disjoint shards
+// (numbered from 0), so that a large corpus can be split between parallel
+// CI jobs. Cases are assigned to shards by the hash of their names, so the
+// assignment of existing cases doesn't change when new cases are added.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Shard(jobIndex, jobCount))
+func Shard(index, total int) option {
+	if total < 1 || index < 0 || index >= total {
+		panic("shard index must be between 0 and the total number of shards")
+	}
+	return func(o *optionSet) {
+		o.shardIndex = index
+		o.shardTotal = total
+	}
+}
+
+// selectCases is an internal function that filters and orders
+// the discovered cases according to the options
+func selectCases(t *testing.T, cases []*testCase, opt *optionSet) []*testCase {
+	if len(opt.tags) > 0 {
+		var tagged []*testCase
+		for _, c := range cases {
+			if hasTag(c, opt.tags) {
+				tagged = append(tagged, c)
+			}
+		}
+		cases = tagged
+	}
+
+	if opt.rerunFailed || (*rerunFailedFlag && opt.historyPath != "") {
+		cases = failedCases(t, cases, opt)
+	}
+
+	if opt.shardTotal > 1 {
+		total := len(cases)
+		cases = shardCases(cases, opt.shardIndex, opt.shardTotal)
+		logf(t, opt, logAlways, "Running shard %d of %d: %d out of %d files",
+			opt.shardIndex, opt.shardTotal, len(cases), total)
+	}
+
+	if opt.sample > 0 && opt.sample < 1 {
+		seed := opt.sampleSeed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		total := len(cases)
+		cases = sampleCases(cases, opt.sample, seed)
+		logf(t, opt, logAlways, "Running a sample of %d out of %d files (seed: %d)", len(cases), total, seed)
+	}
+
+	if opt.prioritize || opt.failedFirst {
+		h, err := readHistory(opt.historyPath)
+		if err != nil {
+			t.Fatalf("Can't read the history file: %v", err)
+		}
+		prioritizeCases(cases, h, opt)
+	}
+
+	cases = expandVariants(cases, opt)
+
+	return cases
+}
+
+// sampleCases is an internal function that picks a deterministic
+// random subset of cases
+func sampleCases(cases []*testCase, fraction float64, seed int64) []*testCase {
+	r := rand.New(rand.NewSource(seed))
+	var sampled []*testCase
+	for _, c := range cases {
+		if r.Float64() < fraction {
+			sampled = append(sampled, c)
+		}
+	}
+	return sampled
+}
+
+// shardCases is an internal function that picks the cases
+// which belong to the shard (see Shard())
+func shardCases(cases []*testCase, index, total int) []*testCase {
+	var shard []*testCase
+	for _, c := range cases {
+		h := fnv.New32a()
+		h.Write([]byte(c.name))
+		if int(h.Sum32()%uint32(total)) == index {
+			shard = append(shard, c)
+		}
+	}
+	return shard
+}
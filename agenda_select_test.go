@@ -0,0 +1,69 @@
+package agenda
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// TestSampleCases is a traditional (non agenda-based) test
+// that checks that sampling is deterministic for the same seed
+func TestSampleCases(t *testing.T) {
+	var cases []*testCase
+	for i := 0; i < 1000; i++ {
+		cases = append(cases, &testCase{path: fmt.Sprintf("%d.json", i)})
+	}
+
+	a := sampleCases(cases, 0.2, 42)
+	b := sampleCases(cases, 0.2, 42)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("Expected the same sample for the same seed")
+	}
+
+	if len(a) < 150 || len(a) > 250 {
+		t.Errorf("Expected about 200 cases, got %d", len(a))
+	}
+
+	if c := sampleCases(cases, 0.2, 43); reflect.DeepEqual(a, c) {
+		t.Error("Expected a different sample for a different seed")
+	}
+}
+
+// TestRunSample runs a sample of agenda tests
+func TestRunSample(t *testing.T) {
+	Run(t, "testdata/01/default", test01, Sample(0.5, 1))
+}
+
+// TestShardCases is a traditional (non agenda-based) test
+// that checks that the shards are disjoint and cover all cases
+func TestShardCases(t *testing.T) {
+	var cases []*testCase
+	for i := 0; i < 1000; i++ {
+		cases = append(cases, &testCase{name: fmt.Sprintf("%d.json", i)})
+	}
+
+	seen := make(map[*testCase]int)
+	for i := 0; i < 4; i++ {
+		shard := shardCases(cases, i, 4)
+		if len(shard) < 150 || len(shard) > 350 {
+			t.Errorf("Expected about 250 cases in shard %d, got %d", i, len(shard))
+		}
+		if !reflect.DeepEqual(shard, shardCases(cases, i, 4)) {
+			t.Errorf("Expected shard %d to be deterministic", i)
+		}
+		for _, c := range shard {
+			seen[c]++
+		}
+	}
+	for _, c := range cases {
+		if seen[c] != 1 {
+			t.Errorf("Expected case %s to be in exactly one shard, got %d", c.name, seen[c])
+		}
+	}
+}
+
+// TestRunShard runs the shards of agenda tests
+func TestRunShard(t *testing.T) {
+	Run(t, "testdata/01/default", test01, Shard(0, 2))
+	Run(t, "testdata/01/default", test01, Shard(1, 2))
+}
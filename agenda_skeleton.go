@@ -0,0 +1,171 @@
+package agenda
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+// Skeleton renders the skeleton of the input data file for the type of `v`
+// (a value or a pointer to it) in JSONC format (see JSONC()): all exported
+// fields are listed with their JSON names and zero values, and each field
+// is preceded by a comment with its Go name and type, so that the new cases
+// for complex input types can be authored quickly. Slices and arrays
+// contain a single element skeleton, maps are empty.
+//
+// Example:
+//
+//		data, err := agenda.Skeleton(Request{})
+//		err = ioutil.WriteFile("testdata/mytest/new-case.json", data, 0644)
+func Skeleton(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, fmt.Errorf("can't render the skeleton of nil")
+	}
+
+	var buf bytes.Buffer
+	if err := writeSkeleton(&buf, reflect.TypeOf(v), "", make(map[reflect.Type]bool)); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// WriteSkeleton writes the skeleton of the input data file for the type
+// of `v` (see Skeleton()) to the file at `path`, refusing to overwrite
+// an existing file
+//
+// Example:
+//
+// err := agenda.WriteSkeleton("testdata/mytest/new-case.json", Request{})
+func WriteSkeleton(path string, v interface{}) error {
+	data, err := Skeleton(v)
+	if err != nil {
+		return err
+	}
+	if _, err := ioutil.ReadFile(path); err == nil {
+		return fmt.Errorf("file '%s' already exists", path)
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// writeSkeleton is an internal function that writes the skeleton
+// value of the type; `visiting` protects from recursive types
+func writeSkeleton(buf *bytes.Buffer, t reflect.Type, indent string, visiting map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		data, err := json.Marshal(reflect.New(t).Interface())
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if visiting[t] {
+			buf.WriteString("null")
+			return nil
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		fields := skeletonFields(t, nil)
+		if len(fields) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i, f := range fields {
+			fmt.Fprintf(buf, "%s\t// %s %s\n%s\t%q: ", indent, f.goName, f.typ, indent, f.name)
+			if err := writeSkeleton(buf, f.typ, indent+"\t", visiting); err != nil {
+				return err
+			}
+			if i < len(fields)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			buf.WriteString(`""`)
+			return nil
+		}
+		buf.WriteString("[\n" + indent + "\t")
+		if err := writeSkeleton(buf, t.Elem(), indent+"\t", visiting); err != nil {
+			return err
+		}
+		buf.WriteString("\n" + indent + "]")
+
+	case reflect.Map:
+		buf.WriteString("{}")
+
+	case reflect.Interface:
+		buf.WriteString("null")
+
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return fmt.Errorf("type %s can't be represented in JSON", t)
+
+	default:
+		data, err := json.Marshal(reflect.Zero(t).Interface())
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// skeletonField describes a single JSON field of a struct
+type skeletonField struct {
+	name   string // JSON name
+	goName string
+	typ    reflect.Type
+}
+
+// skeletonFields is an internal function that returns the JSON fields
+// of the struct type, following the rules of encoding/json for the field
+// names and the embedded structs
+func skeletonFields(t reflect.Type, fields []skeletonField) []skeletonField {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = skeletonFields(ft, fields)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, skeletonField{name: name, goName: f.Name, typ: f.Type})
+	}
+	return fields
+}
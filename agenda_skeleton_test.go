@@ -0,0 +1,51 @@
+package agenda
+
+import (
+	"testing"
+	"time"
+)
+
+// skeletonInput is a sample input type of a test function
+type skeletonInput struct {
+	skeletonMeta
+	Name     string            `json:"name"`
+	Limit    *int              `json:"limit,omitempty"`
+	Created  time.Time         `json:"created"`
+	Items    []skeletonItem    `json:"items"`
+	Labels   map[string]string `json:"labels"`
+	Next     *skeletonInput    `json:"next"`
+	Ignored  bool              `json:"-"`
+	internal int
+}
+
+type skeletonMeta struct {
+	Version int
+}
+
+type skeletonItem struct {
+	ID    int64   `json:"id"`
+	Price float64 `json:"price"`
+	Data  []byte  `json:"data"`
+}
+
+// TestSkeleton takes the snapshot of the skeleton of the input type
+// and checks that it can be parsed back
+func TestSkeleton(t *testing.T) {
+	data, err := Skeleton(&skeletonInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Match(t, data)
+
+	var v skeletonInput
+	if err := UnmarshalJSONC(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Limit == nil || len(v.Items) != 1 || v.Labels == nil || v.Next != nil {
+		t.Errorf("Unexpected decoded skeleton: %+v", v)
+	}
+
+	if _, err := Skeleton(struct{ C chan int }{}); err == nil {
+		t.Error("Expected an error for a type which can't be represented in JSON")
+	}
+}
@@ -0,0 +1,139 @@
+package agenda
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// defaultSnapshotDir is the directory where Snapshot() stores
+// result files unless the ResultDir() option is used
+const defaultSnapshotDir = "testdata/__snapshots__"
+
+// Snapshot compares arbitrary data produced inside an ordinary unit test
+// with the reference result file, without setting up an input directory.
+// The path of the result file is derived from the name of the test,
+// sanitized to be safe to use as a file path (see SanitizeNames()):
+// for TestFoo it is "testdata/__snapshots__/TestFoo.result", and subtests
+// are stored in nested directories ("TestFoo/case_1.result").
+// The directory can be changed with the ResultDir() option.
+// Output transformations (e.g. ScrubTimestamps()) and masks are applied
+// as with Compare(). In initialization mode, the data is saved
+// as the new reference. Taking the same snapshot twice in a test
+// fails it (use MatchNamed() for several snapshots).
+//
+// Example:
+//
+//		func TestRender(t *testing.T) {
+//			agenda.Snapshot(t, Render(page))
+//		}
+func Snapshot(t *testing.T, data []byte, options ...option) {
+	snapshot(t, "", data, options)
+}
+
+// Match is the same as Snapshot, so that converting an existing
+// unit test into a snapshot assertion reads as one line.
+// For TestFoo, the result file is "testdata/__snapshots__/TestFoo.result".
+//
+// Example:
+//
+//		func TestRender(t *testing.T) {
+//			agenda.Match(t, Render(page), agenda.ScrubTimestamps())
+//		}
+func Match(t *testing.T, got []byte, options ...option) {
+	snapshot(t, "", got, options)
+}
+
+// MatchNamed is similar to Match, but allows taking several snapshots
+// within the same test, each stored in its own result file:
+// for TestFoo and the "headers" name, it is
+// "testdata/__snapshots__/TestFoo.headers.result".
+//
+// Example:
+//
+//		func TestResponse(t *testing.T) {
+//			resp := Handle(req)
+//			agenda.MatchNamed(t, "headers", resp.Headers)
+//			agenda.MatchNamed(t, "body", resp.Body)
+//		}
+func MatchNamed(t *testing.T, name string, got []byte, options ...option) {
+	snapshot(t, name, got, options)
+}
+
+// snapshot is an internal function that takes the snapshot
+// with the given name (if any) in the test
+func snapshot(t *testing.T, name string, got []byte, options []option) {
+	opt := newOptionSet(options)
+	guardInit(t, opt)
+
+	path := matchPath(t.Name(), opt)
+	if name != "" {
+		path = strings.TrimSuffix(path, opt.resultSuffix) +
+			"." + sanitizeNameElement(name) + opt.resultSuffix
+	}
+	matchSnapshot(t, path, got, opt)
+}
+
+// matched keeps the result files of the snapshots taken in each running
+// test; tests are keyed by their names, and the entry is removed when
+// the test finishes (see onTestDone()) or reset when the test with
+// the same name runs again (`-count=N`)
+var matched = struct {
+	sync.Mutex
+	tests map[string]*matchedTest
+}{tests: make(map[string]*matchedTest)}
+
+// matchedTest is an internal structure that keeps
+// the snapshots taken in the test instance
+type matchedTest struct {
+	t     *testing.T
+	paths map[string]bool
+}
+
+// matchSnapshot is an internal function that checks the snapshot,
+// failing the test if the same snapshot was already taken in it
+func matchSnapshot(t *testing.T, path string, got []byte, opt *optionSet) {
+	matched.Lock()
+	m := matched.tests[t.Name()]
+	if m == nil || m.t != t {
+		m = &matchedTest{t: t, paths: make(map[string]bool)}
+		matched.tests[t.Name()] = m
+		onTestDone(t, func() { forgetMatched(m) })
+	}
+	taken := m.paths[path]
+	m.paths[path] = true
+	matched.Unlock()
+
+	if taken {
+		t.Errorf("Snapshot '%s' was already taken in this test (use MatchNamed() for several snapshots)", path)
+		return
+	}
+	compareGolden(t, path, got, opt)
+}
+
+// forgetMatched is an internal function that removes the entry
+// of the finished test, unless it was replaced by a newer instance
+func forgetMatched(m *matchedTest) {
+	matched.Lock()
+	defer matched.Unlock()
+
+	if matched.tests[m.t.Name()] == m {
+		delete(matched.tests, m.t.Name())
+	}
+}
+
+// snapshotDir is an internal function that returns the directory
+// where the snapshots of unit tests are stored
+func snapshotDir(opt *optionSet) string {
+	if opt.resultDir != "" {
+		return opt.resultDir
+	}
+	return defaultSnapshotDir
+}
+
+// matchPath is an internal function that returns
+// the result file path for the (slash-separated) snapshot name
+func matchPath(name string, opt *optionSet) string {
+	return filepath.Join(snapshotDir(opt), filepath.FromSlash(sanitizeName(name))) + opt.resultSuffix
+}
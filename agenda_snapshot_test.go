@@ -0,0 +1,62 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// TestSnapshot takes snapshots of values computed inside
+// a traditional test, including its subtests
+func TestSnapshot(t *testing.T) {
+	out, err := test01("", []byte(`{"a":1,"b":2,"c":4}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Snapshot(t, out)
+
+	t.Run("zero", func(t *testing.T) {
+		out, err := test01("", []byte(`{"a":1,"b":0,"c":4}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		Snapshot(t, out)
+	})
+}
+
+// TestSnapshotTransform checks that Snapshot applies output
+// transformations, producing the same result file as Match
+func TestSnapshotTransform(t *testing.T) {
+	Snapshot(t, []byte("updated at 2020-01-02T03:04:05Z\n"), ScrubTimestamps())
+}
+
+// TestMatch takes snapshots of values computed inside a traditional test,
+// including a subtest whose name needs to be sanitized
+func TestMatch(t *testing.T) {
+	Match(t, []byte("updated at 2020-01-02T03:04:05Z\n"), ScrubTimestamps())
+
+	t.Run(`C:\path`, func(t *testing.T) {
+		out, err := test01("", []byte(`{"a":1,"b":2,"c":4}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		Match(t, out)
+	})
+}
+
+// TestMatchNamed takes several named snapshots within the same test
+func TestMatchNamed(t *testing.T) {
+	MatchNamed(t, "headers", []byte("Content-Type: application/json\n"))
+	MatchNamed(t, "body", []byte("{\"ok\":true}\n"))
+}
+
+// TestMatchRerun checks that the snapshots taken by the previous run
+// of the test with the same name (`-count=N`) are not reported as taken
+func TestMatchRerun(t *testing.T) {
+	matched.Lock()
+	matched.tests[t.Name()] = &matchedTest{
+		t:     &testing.T{},
+		paths: map[string]bool{matchPath(t.Name(), newOptionSet(nil)): true},
+	}
+	matched.Unlock()
+
+	Match(t, []byte("rerun\n"))
+}
@@ -0,0 +1,128 @@
+package agenda
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+	"time"
+)
+
+// stamps is an internal structure that represents the contents
+// of the staleness file; results are keyed by their file path
+type stamps struct {
+	Results map[string]*resultStamp `json:"results"`
+}
+
+// resultStamp records when the contents of the result file were created
+type resultStamp struct {
+	Created time.Time `json:"created"`
+	SHA256  string    `json:"sha256"`
+}
+
+// Staleness enables tracking of the creation time of the result files
+// in the file at `path`, to prompt periodic re-validation of long-untouched
+// reference data. The creation time is recorded in initialization mode
+// whenever the contents of the result file change; in regular mode,
+// a warning is logged for each result file older than `maxAge`.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc,
+//     agenda.Staleness("testdata/.stamps.json", 180*24*time.Hour))
+func Staleness(path string, maxAge time.Duration) option {
+	return func(o *optionSet) {
+		o.stampsPath = path
+		o.maxAge = maxAge
+	}
+}
+
+// readStamps is an internal function that reads the staleness file;
+// a missing file results in no recorded stamps
+func readStamps(path string) (*stamps, error) {
+	s := &stamps{Results: make(map[string]*resultStamp)}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Results == nil {
+		s.Results = make(map[string]*resultStamp)
+	}
+	return s, nil
+}
+
+// updateStamps is an internal function that records the creation time
+// of the result files whose contents changed in initialization mode
+func updateStamps(t *testing.T, cases []*testCase, opt *optionSet) {
+	s, err := readStamps(opt.stampsPath)
+	if err != nil {
+		t.Errorf("Can't read the staleness file: %v", err)
+		return
+	}
+
+	readFile := ioutil.ReadFile
+	if r, ok := opt.fs.(fileReader); ok {
+		readFile = r.ReadFile
+	}
+
+	now := time.Now().UTC()
+	for _, c := range cases {
+		if !c.ran || c.failed {
+			continue
+		}
+		data, err := readFile(c.resultPath)
+		if err != nil {
+			continue
+		}
+		sum := digest(data)
+		if rs := s.Results[c.resultPath]; rs != nil && rs.SHA256 == sum {
+			continue
+		}
+		s.Results[c.resultPath] = &resultStamp{Created: now, SHA256: sum}
+	}
+
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		t.Errorf("Can't serialize the staleness file: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(opt.stampsPath, data, 0644); err != nil {
+		t.Errorf("Can't save the staleness file: %v", err)
+	}
+}
+
+// reportStaleness is an internal function that logs a warning
+// for each result file older than the maximum age
+func reportStaleness(t *testing.T, cases []*testCase, opt *optionSet) {
+	s, err := readStamps(opt.stampsPath)
+	if err != nil {
+		t.Errorf("Can't read the staleness file: %v", err)
+		return
+	}
+
+	var stale []string
+	for _, c := range cases {
+		rs := s.Results[c.resultPath]
+		if !c.ran || rs == nil {
+			continue
+		}
+		if age := time.Since(rs.Created); age > opt.maxAge {
+			stale = append(stale, c.resultPath)
+		}
+	}
+	sort.Strings(stale)
+
+	for _, path := range stale {
+		logf(t, opt, logAlways, "Warning: result file '%s' was created on %s, more than %v ago; consider re-validating it",
+			path, s.Results[path].Created.Format("2006-01-02"), opt.maxAge)
+	}
+}
@@ -0,0 +1,61 @@
+package agenda
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStaleness is a traditional (non agenda-based) test that checks
+// that the creation time of the result files is recorded in initialization
+// mode, and that old result files are reported in regular mode
+func TestStaleness(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "stamps.json")
+	week := 7 * 24 * time.Hour
+	Run(t, "testdata/01/default", test01, InitMode(true), Output(NewMemFS()), Staleness(path, week))
+
+	s, err := readStamps(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Results) != 4 {
+		t.Fatalf("Expected 4 result files to be recorded, got %d", len(s.Results))
+	}
+
+	// backdate the first result file
+	resultPath := filepath.Join("testdata/01/default", "1.json.result")
+	old := time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+	s.Results[resultPath].Created = old
+	data, _ := json.Marshal(s)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	Run(t, "testdata/01/default", test01, Staleness(path, week), Logger(log.New(&buf, "", 0)))
+	if !strings.Contains(buf.String(), "Warning: result file '"+resultPath+"' was created on 2001-02-03") ||
+		strings.Count(buf.String(), "Warning:") != 1 {
+		t.Errorf("Expected a single staleness warning, got:\n%s", buf.String())
+	}
+
+	// unchanged result files keep their creation time
+	Run(t, "testdata/01/default", test01, InitMode(true), Output(NewMemFS()), Staleness(path, week))
+	if s, err = readStamps(path); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Results[resultPath].Created.Equal(old) {
+		t.Errorf("Expected the creation time to be preserved, got %v", s.Results[resultPath].Created)
+	}
+}
@@ -0,0 +1,108 @@
+package agenda
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Strict enables the strict directory mode which fails the test if the test
+// data directory contains files that are neither input data files nor the files
+// that belong to them (result files, error snapshots, environment files,
+// pending changes), nor the files agenda itself maintains (manifests,
+// "cases.json"), nor the fixtures declared with Fixtures(). This catches
+// typos in file extensions that silently drop cases from the corpus.
+// Hidden files (starting with a dot) are ignored.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Strict(), agenda.Fixtures("common/*.json"))
+func Strict() option {
+	return func(o *optionSet) {
+		o.strict = true
+	}
+}
+
+// Fixtures declares the files in the test data directory which are
+// not cases, but are shared by them (see Strict()). Patterns are matched
+// (using path.Match syntax) against the slash-separated file paths relative
+// to the test data directory, and against the file names.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Strict(), agenda.Fixtures("*.cassette", "common/*"))
+func Fixtures(patterns ...string) option {
+	return func(o *optionSet) {
+		o.fixtures = append(o.fixtures, patterns...)
+	}
+}
+
+// checkStrict is an internal function that fails the test if the directory
+// contains unrecognized files (see Strict())
+func checkStrict(t *testing.T, dir string, cases []*testCase, opt *optionSet) {
+	unknown, err := unknownFiles(dir, cases, opt)
+	if err != nil {
+		t.Fatalf("Can't read the directory contents: %v", err)
+	}
+	for _, path := range unknown {
+		t.Errorf("File '%s' is neither an input data file, nor a result file, nor a declared fixture", path)
+	}
+}
+
+// unknownFiles is an internal function that returns the sorted list
+// of unrecognized files in the directory (see Strict())
+func unknownFiles(dir string, cases []*testCase, opt *optionSet) ([]string, error) {
+	known := make(map[string]bool)
+	for _, name := range []string{caseListName, manifestName, manifestName + signatureSuffix} {
+		known[filepath.Join(dir, name)] = true
+	}
+	for _, path := range []string{opt.historyPath, opt.stampsPath, opt.patchPath, opt.reportPath, opt.schemaPath, opt.cachePath} {
+		if path != "" {
+			known[filepath.Clean(path)] = true
+		}
+	}
+	for _, c := range append(cases, expandVariants(cases, opt)...) {
+		for _, path := range []string{
+			c.path, c.path + envSuffix,
+			c.resultPath, c.resultPath + pendingSuffix, c.errorPath(opt),
+			caseOptions(c, opt).schemaPath,
+		} {
+			known[filepath.Clean(path)] = true
+		}
+	}
+
+	var unknown []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && (!opt.recursive || known[path]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if known[path] || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		for _, pattern := range opt.fixtures {
+			if matched, _ := filepath.Match(pattern, filepath.ToSlash(rel)); matched {
+				return nil
+			}
+			if matched, _ := filepath.Match(pattern, info.Name()); matched {
+				return nil
+			}
+		}
+		unknown = append(unknown, path)
+		return nil
+	})
+	sort.Strings(unknown)
+	return unknown, err
+}
@@ -0,0 +1,51 @@
+package agenda
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestStrict runs agenda tests in the strict directory mode
+func TestStrict(t *testing.T) {
+	Run(t, "testdata/01/default", test01, Strict(), Fixtures("*.ignored"))
+	Run(t, "testdata/recursive", test01, Recursive(), Strict())
+	Run(t, "testdata/matrix", testFlagged, Strict(),
+		Matrix(FlagCombinations("AGENDA_TEST_LOUD", "AGENDA_TEST_EXCITED")...))
+}
+
+// TestUnknownFiles is a traditional (non agenda-based) test
+// that checks which files are not recognized in the strict directory mode
+func TestUnknownFiles(t *testing.T) {
+	var tests = []struct {
+		options []option
+		want    []string
+	}{
+		{nil, []string{"5.json.ignored"}},
+		{[]option{Fixtures("*.ignored")}, nil},
+		{[]option{Fixtures("5.*")}, nil},
+		{[]option{FileSuffix(".ignored")}, []string{"1.json", "1.json.result", "2.json", "2.json.result",
+			"3.json", "3.json.result", "4.json", "4.json.result"}},
+	}
+
+	for _, test := range tests {
+		dir := "testdata/01/default"
+		opt := newOptionSet(test.options)
+		cases, err := listCases(dir, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unknown, err := unknownFiles(dir, cases, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, path := range unknown {
+			names = append(names, filepath.Base(path))
+		}
+		if strings.Join(names, " ") != strings.Join(test.want, " ") {
+			t.Errorf("Expected %v, got %v", test.want, names)
+		}
+	}
+}
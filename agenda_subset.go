@@ -0,0 +1,72 @@
+package agenda
+
+// CompareSubset enables the subset comparison mode for JSON outputs:
+// the reference data only needs to be contained in the generated output,
+// and the object keys which are missing in the reference data are ignored,
+// so that only the fields under test need to be kept in the result files
+// (e.g. for responses of evolving APIs). Arrays are still compared element
+// by element. Both sides are re-encoded before they are compared, and
+// outputs which are not valid JSON are compared as is. In initialization
+// mode, the whole output is saved; trim the result files by hand afterwards.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.CompareSubset())
+func CompareSubset() option {
+	return func(o *optionSet) {
+		o.normalizers = append(o.normalizers, func(reference, output []byte) ([]byte, []byte) {
+			want, err := decodeJSON(reference)
+			if err != nil {
+				return reference, output
+			}
+			got, err := decodeJSON(output)
+			if err != nil {
+				return reference, output
+			}
+
+			r, err := encodeCanonical(want, "\t")
+			if err != nil {
+				return reference, output
+			}
+			g, err := encodeCanonical(pruneJSON(want, got), "\t")
+			if err != nil {
+				return reference, output
+			}
+			return r, g
+		})
+	}
+}
+
+// pruneJSON is an internal function that recursively removes
+// the object keys of the output which are missing in the reference
+func pruneJSON(want, got interface{}) interface{} {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+		pruned := make(map[string]interface{}, len(w))
+		for k, wv := range w {
+			if gv, ok := g[k]; ok {
+				pruned[k] = pruneJSON(wv, gv)
+			}
+		}
+		return pruned
+
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+		pruned := make([]interface{}, len(g))
+		for i, gv := range g {
+			if i < len(w) {
+				gv = pruneJSON(w[i], gv)
+			}
+			pruned[i] = gv
+		}
+		return pruned
+	}
+	return got
+}
@@ -0,0 +1,29 @@
+package agenda
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCompareSubset is a traditional (non agenda-based) test that compares
+// JSON outputs with extra keys against the reference data
+func TestCompareSubset(t *testing.T) {
+	opt := newOptionSet([]option{CompareSubset()})
+	reference := []byte(`{"id": 1, "items": [{"name": "a"}, {"name": "b"}]}`)
+
+	var r fakeReporter
+	output := []byte(`{"id":1,"etag":"x","items":[{"name":"a","price":1},{"name":"b","price":2}]}`)
+	compareOutput(&r, "out.json.result", reference, output, opt)
+	if len(r.errors) != 0 {
+		t.Errorf("Expected no differences, got %v", r.errors)
+	}
+
+	output = []byte(`{"etag":"x","items":[{"name":"a","price":1},{"name":"c"}]}`)
+	compareOutput(&r, "out.json.result", reference, output, opt)
+	if len(r.errors) != 1 {
+		t.Fatalf("Expected 1 error, got %v", r.errors)
+	}
+	if strings.Contains(r.errors[0], "etag") || strings.Contains(r.errors[0], "price") {
+		t.Errorf("Expected the extra keys to be excluded from the diff, got:\n%s", r.errors[0])
+	}
+}
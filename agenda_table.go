@@ -0,0 +1,208 @@
+package agenda
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ExportTable converts the table of a table-driven test (a slice or an array
+// of structs) into the directory of input data files, one per row, to ease
+// the migration to agenda-based tests. Files are named after the (1-based)
+// row number with the input file suffix ("1.json", "2.json" and so on),
+// and contain the fields of the row encoded as JSON objects with the names
+// from their `json` tags, or the Go field names. Unexported fields of basic
+// types (as commonly used in tables) are supported. Existing files are
+// not overwritten. Run the tests in initialization mode afterwards
+// to create the result files.
+//
+// Example:
+//
+//		var tests = []struct {
+//			a, b int
+//		}{
+//			{1, 2},
+//			{2, 3},
+//		}
+//		err := agenda.ExportTable("testdata/sum", tests)
+func ExportTable(dir string, table interface{}, options ...option) error {
+	opt := newOptionSet(options)
+
+	v := reflect.ValueOf(table)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array || v.Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("table must be a slice or an array of structs, got %T", table)
+	}
+
+	if err := os.MkdirAll(dir, opt.dirMode); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		row, err := tableRow(v.Index(i))
+		if err != nil {
+			return fmt.Errorf("row %d: %v", i+1, err)
+		}
+		data, err := json.Marshal(row)
+		if err != nil {
+			return fmt.Errorf("row %d: %v", i+1, err)
+		}
+
+		path := filepath.Join(dir, strconv.Itoa(i+1)+opt.fileSuffix)
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("file '%s' already exists", path)
+		}
+		if err := writeFileMode(path, data, opt.fileMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TableLiteral renders the input data files of the directory as the Go
+// literal of the table for a table-driven test, with the type of the rows
+// taken from `row` (a struct value), which is the inverse of ExportTable().
+// Fields are matched by their `json` tags or Go field names, and the keys
+// missing in the input data (or null) are omitted from the rows.
+//
+// Example:
+//
+//		type row struct {
+//			a, b int
+//		}
+//		literal, err := agenda.TableLiteral("testdata/sum", row{})
+func TableLiteral(dir string, row interface{}, options ...option) (string, error) {
+	opt := newOptionSet(options)
+
+	t := reflect.TypeOf(row)
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", fmt.Errorf("row must be a struct, got %T", row)
+	}
+
+	cases, err := listCases(dir, opt)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[]%s{\n", t)
+	for _, c := range cases {
+		data, err := c.readInput(opt)
+		if err != nil {
+			return "", err
+		}
+		v, err := decodeJSON(data)
+		if err != nil {
+			return "", fmt.Errorf("can't decode '%s': %v", c.path, err)
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("'%s' doesn't contain a JSON object", c.path)
+		}
+
+		var fields []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, ok := tableFieldName(f)
+			if !ok {
+				continue
+			}
+			raw, ok := m[name]
+			if !ok || raw == nil {
+				continue
+			}
+			literal, err := fieldLiteral(f, raw)
+			if err != nil {
+				return "", fmt.Errorf("'%s', field %s: %v", c.path, f.Name, err)
+			}
+			fields = append(fields, f.Name+": "+literal)
+		}
+		fmt.Fprintf(&b, "\t{%s}, // %s\n", strings.Join(fields, ", "), c.name)
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// tableFieldName is an internal function that returns the JSON name
+// of the struct field, or false if the field is ignored
+func tableFieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, true
+	}
+	return f.Name, true
+}
+
+// tableRow is an internal function that converts the row of the table
+// into the map of its fields, reading the unexported fields of basic types
+func tableRow(v reflect.Value) (map[string]interface{}, error) {
+	row := make(map[string]interface{})
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		name, ok := tableFieldName(f)
+		if !ok {
+			continue
+		}
+		if f.PkgPath == "" {
+			row[name] = v.Field(i).Interface()
+			continue
+		}
+
+		fv := v.Field(i)
+		switch fv.Kind() {
+		case reflect.String:
+			row[name] = fv.String()
+		case reflect.Bool:
+			row[name] = fv.Bool()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			row[name] = fv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			row[name] = fv.Uint()
+		case reflect.Float32, reflect.Float64:
+			row[name] = fv.Float()
+		default:
+			return nil, fmt.Errorf("unexported field %s of type %s is not supported", f.Name, f.Type)
+		}
+	}
+	return row, nil
+}
+
+// fieldLiteral is an internal function that renders the decoded
+// JSON value as the Go literal of the struct field type
+func fieldLiteral(f reflect.StructField, raw interface{}) (string, error) {
+	switch f.Type.Kind() {
+	case reflect.String:
+		if s, ok := raw.(string); ok {
+			return strconv.Quote(s), nil
+		}
+	case reflect.Bool:
+		if b, ok := raw.(bool); ok {
+			return strconv.FormatBool(b), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if n, ok := raw.(json.Number); ok {
+			return n.String(), nil
+		}
+	default:
+		if f.PkgPath != "" {
+			return "", fmt.Errorf("unexported field of type %s is not supported", f.Type)
+		}
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return "", err
+		}
+		v := reflect.New(f.Type)
+		if err := json.Unmarshal(data, v.Interface()); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%#v", v.Elem().Interface()), nil
+	}
+	return "", fmt.Errorf("can't use %s as %s", formatJSON(raw), f.Type)
+}
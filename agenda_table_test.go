@@ -0,0 +1,59 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestTable is a traditional (non agenda-based) test that converts
+// the table into the directory of input data files and back
+func TestTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	type row struct {
+		a, b   int
+		op     string
+		Tags   []string `json:"tags,omitempty"`
+		result float64
+	}
+	tests := []row{
+		{1, 2, "+", nil, 3},
+		{5, 2, "/", []string{"div"}, 2.5},
+	}
+
+	if err := ExportTable(dir, tests); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"a":5,"b":2,"op":"/","result":2.5,"tags":["div"]}`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	if err := ExportTable(dir, tests); err == nil {
+		t.Error("Expected an error when overwriting the existing files")
+	}
+	if err := ExportTable(dir, []int{1}); err == nil {
+		t.Error("Expected an error for a table of non-structs")
+	}
+
+	literal, err := TableLiteral(dir, row{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[]agenda.row{\n" +
+		"\t{a: 1, b: 2, op: \"+\", result: 3}, // 1.json\n" +
+		"\t{a: 5, b: 2, op: \"/\", Tags: []string{\"div\"}, result: 2.5}, // 2.json\n" +
+		"}\n"
+	if literal != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, literal)
+	}
+}
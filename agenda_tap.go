@@ -0,0 +1,75 @@
+package agenda
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// TAP makes agenda write the outcome of each case to `w` in the TAP
+// (Test Anything Protocol) version 13 format, so that agenda runs can be
+// consumed by TAP-based harnesses and aggregators. Each processed case
+// produces an "ok" or "not ok" line with the path of the input file;
+// the failure messages follow as a YAML diagnostic block. The plan line
+// is written at the end of the run, since skipped cases are not reported.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.TAP(os.Stdout))
+func TAP(w io.Writer) option {
+	return Observe(&tapObserver{w: w})
+}
+
+// tapObserver is an internal structure that
+// writes the TAP stream (see TAP())
+type tapObserver struct {
+	NopObserver
+	mu sync.Mutex
+	w  io.Writer
+	n  int
+}
+
+func (o *tapObserver) OnRunStart(total int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.n = 0
+	fmt.Fprintln(o.w, "TAP version 13")
+}
+
+func (o *tapObserver) OnCasePass(path string) {
+	o.writeCase("ok", path, "")
+}
+
+func (o *tapObserver) OnCaseFail(path string, report string) {
+	o.writeCase("not ok", path, report)
+}
+
+func (o *tapObserver) OnCaseInit(path string) {
+	o.writeCase("ok", path, "")
+}
+
+func (o *tapObserver) OnRunEnd(summary Summary) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fmt.Fprintf(o.w, "1..%d\n", o.n)
+}
+
+// writeCase writes the test point of the case
+// followed by the diagnostic block with the report, if any
+func (o *tapObserver) writeCase(status, path, report string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.n++
+	// '#' starts a directive in the description
+	fmt.Fprintf(o.w, "%s %d - %s\n", status, o.n, strings.Replace(path, "#", `\#`, -1))
+	if report == "" {
+		return
+	}
+	fmt.Fprintln(o.w, "  ---")
+	fmt.Fprintln(o.w, "  message: |")
+	for _, line := range strings.Split(strings.TrimRight(report, "\n"), "\n") {
+		fmt.Fprintf(o.w, "    %s\n", line)
+	}
+	fmt.Fprintln(o.w, "  ...")
+}
@@ -0,0 +1,42 @@
+package agenda
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestTAP is a traditional (non agenda-based) test
+// that checks the TAP stream of the run
+func TestTAP(t *testing.T) {
+	var buf bytes.Buffer
+	Run(t, "testdata/recursive", test01, Recursive(), TAP(&buf))
+
+	want := strings.Join([]string{
+		"TAP version 13",
+		"ok 1 - testdata/recursive/1.json",
+		"ok 2 - testdata/recursive/api/2.json",
+		"ok 3 - testdata/recursive/api/v2/3.json",
+		"ok 4 - testdata/recursive/api/v2/4.json",
+		"1..4",
+	}, "\n") + "\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected TAP output:\n%s", buf.String())
+	}
+
+	// failures are reported in shadow mode, so that this test doesn't fail
+	buf.Reset()
+	Run(t, "testdata/shadow", test01, Shadow(nil), TAP(&buf))
+	failed := 0
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "not ok ") {
+			failed++
+		}
+	}
+	if failed != 2 {
+		t.Errorf("Expected 2 failed test points, got %d", failed)
+	}
+	if !strings.Contains(buf.String(), "  ---\n  message: |\n    ") || !strings.HasSuffix(buf.String(), "1..3\n") {
+		t.Errorf("Expected the diagnostic blocks and the plan, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,40 @@
+package agenda
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// TempDirTest defines the callback function of an agenda test which needs
+// to write intermediate files: it takes the same arguments as Test, along
+// with the path to the empty temporary directory unique to the call
+type TempDirTest func(path string, data []byte, tempDir string) ([]byte, error)
+
+// RunWithTempDir is similar to Run, but passes the test function a new
+// temporary directory on each call (i.e. for each case and each retry),
+// which is removed after the test function returns, so that the tests
+// which write intermediate files don't have to manage their own
+// temporary directories.
+//
+// Example:
+//
+//		agenda.RunWithTempDir(t, "./testdata/mytest", func(path string, data []byte, tempDir string) ([]byte, error) {
+//			return compile(data, filepath.Join(tempDir, "out.bin"))
+//		})
+func RunWithTempDir(t *testing.T, dir string, test TempDirTest, options ...option) {
+	if test == nil {
+		panic("test function is nil")
+	}
+
+	runDirs(t, []string{dir}, func(path string, data []byte) ([]byte, error) {
+		tempDir, err := ioutil.TempDir("", "agenda-case")
+		if err != nil {
+			return nil, fmt.Errorf("can't create the temporary directory: %v", err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		return test(path, data, tempDir)
+	}, newOptionSet(options))
+}
@@ -0,0 +1,39 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRunWithTempDir runs agenda tests which write intermediate files
+// and checks that the temporary directories are unique and removed
+func TestRunWithTempDir(t *testing.T) {
+	seen := make(map[string]bool)
+	RunWithTempDir(t, "testdata/01/default", func(path string, data []byte, tempDir string) ([]byte, error) {
+		if seen[tempDir] {
+			t.Errorf("Temporary directory '%s' is reused", tempDir)
+		}
+		seen[tempDir] = true
+
+		p := filepath.Join(tempDir, "input.json")
+		if err := ioutil.WriteFile(p, data, 0644); err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		return test01(path, data)
+	})
+
+	if len(seen) != 4 {
+		t.Errorf("Expected 4 temporary directories, got %d", len(seen))
+	}
+	for dir := range seen {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("Expected temporary directory '%s' to be removed, got %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package agenda
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// Template enables the expansion of the input data files as text/template
+// templates before they are passed to the test function, so that fixtures
+// can reference dynamic values (e.g. today's date or a base URL). `data` is
+// available as the template data (referencing a missing key is an error), and
+// the `env` function returns the value of the environment variable.
+//
+// Example:
+//
+//		// 01.json: {"url": "{{.BaseURL}}/users", "home": "{{env "HOME"}}"}
+//		agenda.Run(t, "./testdata/mytest", testFunc, agenda.Template(map[string]interface{}{
+//			"BaseURL": server.URL,
+//		}))
+func Template(data map[string]interface{}) option {
+	return func(o *optionSet) {
+		o.inputTransforms = append(o.inputTransforms, func(path string, input []byte) ([]byte, error) {
+			return expandTemplate(path, input, data)
+		})
+	}
+}
+
+// expandTemplate is an internal function that executes
+// the input data as a template (see Template())
+func expandTemplate(path string, input []byte, data map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New(filepath.Base(path)).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"env": os.Getenv}).
+		Parse(string(input))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,29 @@
+package agenda
+
+import (
+	"os"
+	"testing"
+)
+
+// TestTemplate runs agenda tests with the input data files
+// expanded as templates
+func TestTemplate(t *testing.T) {
+	os.Setenv("AGENDA_TEST_C", "4")
+	defer os.Unsetenv("AGENDA_TEST_C")
+
+	Run(t, "testdata/template", test01, Template(map[string]interface{}{
+		"A": 2,
+		"B": 3,
+	}))
+}
+
+// TestExpandTemplate is a traditional (non agenda-based) test
+// that checks that template errors are reported
+func TestExpandTemplate(t *testing.T) {
+	if _, err := expandTemplate("1.json", []byte(`{"a":{{.Missing}}}`), map[string]interface{}{}); err == nil {
+		t.Errorf("Expected an error for the missing key")
+	}
+	if _, err := expandTemplate("1.json", []byte(`{"a":{{.A}`), nil); err == nil {
+		t.Errorf("Expected a parsing error")
+	}
+}
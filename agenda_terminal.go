@@ -0,0 +1,272 @@
+package agenda
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Terminal is a shortcut option that renders the output of the test function
+// on a virtual terminal of the specified size (see RenderTerminal()), and uses
+// the final screen contents as the output, for golden tests of TUI and progress
+// bar rendering.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Terminal(80, 24))
+func Terminal(width, height int) option {
+	return TransformOutput(func(path string, data []byte) ([]byte, error) {
+		return RenderTerminal(data, width, height), nil
+	})
+}
+
+// RenderTerminal interprets the data as it would be displayed by a terminal
+// of `width` columns and `height` rows (0 means the screen grows as needed),
+// and returns the final screen contents with the trailing whitespace trimmed.
+// Carriage returns, backspaces, tabs, line wrapping, scrolling and the common
+// ANSI escape sequences (cursor movement, erasing, saving and restoring the
+// cursor) are interpreted; colors and other attributes are dropped.
+func RenderTerminal(data []byte, width, height int) []byte {
+	if width <= 0 {
+		panic("terminal width must be positive")
+	}
+	s := &screen{width: width, height: height}
+	s.write(data)
+	return s.contents()
+}
+
+// screen is an internal structure that holds
+// the state of the virtual terminal
+type screen struct {
+	width, height int
+	lines         [][]rune
+	row, col      int
+	savedRow      int
+	savedCol      int
+}
+
+// write interprets the data and updates the screen
+func (s *screen) write(data []byte) {
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+
+		switch r {
+		case '\n':
+			s.lineFeed()
+			s.col = 0
+		case '\r':
+			s.col = 0
+		case '\b':
+			if s.col > 0 {
+				s.col--
+			}
+		case '\t':
+			s.col = (s.col/8 + 1) * 8
+			if s.col >= s.width {
+				s.col = s.width - 1
+			}
+		case '\a':
+		case 0x1b:
+			data = s.escape(data)
+		default:
+			if r < ' ' || r == 0x7f {
+				continue
+			}
+			s.put(r)
+		}
+	}
+}
+
+// put prints the rune at the cursor, wrapping the line if needed
+func (s *screen) put(r rune) {
+	if s.col >= s.width {
+		s.lineFeed()
+		s.col = 0
+	}
+	line := s.line(s.row)
+	for len(*line) <= s.col {
+		*line = append(*line, ' ')
+	}
+	(*line)[s.col] = r
+	s.col++
+}
+
+// lineFeed moves the cursor down, scrolling the screen if needed
+func (s *screen) lineFeed() {
+	s.row++
+	if s.height > 0 && s.row >= s.height {
+		s.line(s.row)
+		s.lines = s.lines[s.row-s.height+1:]
+		s.row = s.height - 1
+	}
+}
+
+// line returns the line of the screen, adding the missing lines
+func (s *screen) line(row int) *[]rune {
+	for len(s.lines) <= row {
+		s.lines = append(s.lines, nil)
+	}
+	return &s.lines[row]
+}
+
+// escape interprets the escape sequence at the beginning
+// of the data (after the ESC character) and returns the rest
+func (s *screen) escape(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+
+	switch data[0] {
+	case '[':
+		end := 1
+		for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+			end++
+		}
+		if end == len(data) {
+			return nil
+		}
+		s.csi(string(data[1:end]), data[end])
+		return data[end+1:]
+
+	case ']':
+		// operating system command, terminated by BEL or ST
+		for i := 1; i < len(data); i++ {
+			if data[i] == '\a' {
+				return data[i+1:]
+			}
+			if data[i] == 0x1b && i+1 < len(data) && data[i+1] == '\\' {
+				return data[i+2:]
+			}
+		}
+		return nil
+
+	case '7':
+		s.savedRow, s.savedCol = s.row, s.col
+	case '8':
+		s.row, s.col = s.savedRow, s.savedCol
+	}
+	return data[1:]
+}
+
+// csi interprets the control sequence with the parameters
+func (s *screen) csi(params string, final byte) {
+	if strings.HasPrefix(params, "?") {
+		return // private modes, e.g. cursor visibility
+	}
+
+	var args []int
+	for _, p := range strings.Split(params, ";") {
+		n, _ := strconv.Atoi(p)
+		args = append(args, n)
+	}
+	arg := func(i, def int) int {
+		if i < len(args) && args[i] > 0 {
+			return args[i]
+		}
+		return def
+	}
+
+	switch final {
+	case 'A':
+		s.row -= arg(0, 1)
+	case 'B':
+		s.row += arg(0, 1)
+	case 'C':
+		s.col += arg(0, 1)
+	case 'D':
+		s.col -= arg(0, 1)
+	case 'E':
+		s.row, s.col = s.row+arg(0, 1), 0
+	case 'F':
+		s.row, s.col = s.row-arg(0, 1), 0
+	case 'G':
+		s.col = arg(0, 1) - 1
+	case 'H', 'f':
+		s.row, s.col = arg(0, 1)-1, arg(1, 1)-1
+	case 'J':
+		s.eraseDisplay(arg(0, 0))
+	case 'K':
+		s.eraseLine(s.row, arg(0, 0))
+	case 's':
+		s.savedRow, s.savedCol = s.row, s.col
+	case 'u':
+		s.row, s.col = s.savedRow, s.savedCol
+	}
+	s.clampCursor()
+}
+
+// clampCursor keeps the cursor within the screen
+func (s *screen) clampCursor() {
+	if s.row < 0 {
+		s.row = 0
+	}
+	if s.height > 0 && s.row >= s.height {
+		s.row = s.height - 1
+	}
+	if s.col < 0 {
+		s.col = 0
+	}
+	if s.col >= s.width {
+		s.col = s.width - 1
+	}
+}
+
+// eraseDisplay erases the screen from the cursor to the end (0),
+// from the beginning to the cursor (1), or entirely (2)
+func (s *screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseLine(s.row, 0)
+		if s.row+1 < len(s.lines) {
+			s.lines = s.lines[:s.row+1]
+		}
+	case 1:
+		for row := 0; row < s.row && row < len(s.lines); row++ {
+			s.lines[row] = nil
+		}
+		s.eraseLine(s.row, 1)
+	default:
+		s.lines = nil
+	}
+}
+
+// eraseLine erases the line from the cursor to the end (0),
+// from the beginning to the cursor (1), or entirely (2)
+func (s *screen) eraseLine(row, mode int) {
+	if row >= len(s.lines) {
+		return
+	}
+	line := s.lines[row]
+	switch mode {
+	case 0:
+		if s.col < len(line) {
+			s.lines[row] = line[:s.col]
+		}
+	case 1:
+		for i := 0; i <= s.col && i < len(line); i++ {
+			line[i] = ' '
+		}
+	default:
+		s.lines[row] = nil
+	}
+}
+
+// contents returns the text of the screen without the trailing whitespace
+func (s *screen) contents() []byte {
+	var buf bytes.Buffer
+	blank := 0
+	for _, line := range s.lines {
+		text := strings.TrimRight(string(line), " ")
+		if text == "" {
+			blank++
+			continue
+		}
+		buf.WriteString(strings.Repeat("\n", blank))
+		blank = 0
+		buf.WriteString(text)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
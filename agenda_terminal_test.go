@@ -0,0 +1,48 @@
+package agenda
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestRenderTerminal is a traditional (non agenda-based) test
+// that renders the output of the common terminal tricks
+func TestRenderTerminal(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		width    int
+		height   int
+		expected string
+	}{
+		{"progress", "[    ] 0%\r[==  ] 50%\r[====] 100%\n", 80, 0, "[====] 100%\n"},
+		{"colors", "\x1b[1;31merror\x1b[0m: failed\x1b]0;title\a\n", 80, 0, "error: failed\n"},
+		{"wrap", "abcdefgh", 3, 0, "abc\ndef\ngh\n"},
+		{"scroll", "1\n2\n3\n4", 80, 2, "3\n4\n"},
+		{"erase", "hello world\x1b[6D\x1b[K\x1b[2Ebye", 80, 0, "hello\n\nbye\n"},
+		{"position", "\x1b[2J\x1b[2;3Hx\x1b[1;1Hy\x1b[?25l", 80, 0, "y\n  x\n"},
+		{"backspace", "abc\b\bX\tY", 80, 0, "aXc     Y\n"},
+		{"save", "a\x1b[sbc\x1b[uZ", 80, 0, "aZc\n"},
+		{"scroll-empty", "\n\n\n", 10, 2, ""},
+		{"scroll-positioned", "\x1b[3;1H\n", 10, 2, ""},
+		{"scroll-positioned-text", "a\x1b[3;1Hb\nc", 10, 2, "b\nc\n"},
+	}
+	for _, test := range tests {
+		out := string(RenderTerminal([]byte(test.data), test.width, test.height))
+		if out != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, out)
+		}
+	}
+}
+
+// TestTerminal takes the snapshot of the progress bar rendering
+func TestTerminal(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i <= 10; i++ {
+		fmt.Fprintf(&b, "\r\x1b[32m%-10s\x1b[0m %3d%%", strings.Repeat("#", i), i*10)
+	}
+	b.WriteString("\ndone\n")
+
+	Match(t, []byte(b.String()), Terminal(20, 5))
+}
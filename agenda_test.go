@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"testing"
 )
 
@@ -89,41 +87,27 @@ func TestBinarySerializer(t *testing.T) {
 // by previous tests matches the expectations
 func TestDirectorySnapshotRun01Default(t *testing.T) {
 	Run(t, "testdata/dir-snapshots", func(path string, data []byte) ([]byte, error) {
-		type FileRec struct {
-			Path string `json:"path"`
-			Size int64  `json:"size"`
-		}
-
 		in := struct {
 			Path string `json:"path"`
 		}{}
 
-		out := make([]*FileRec, 0)
-
 		if err := json.Unmarshal(data, &in); err != nil {
 			return nil, err
 		}
 
-		if err := filepath.Walk(
-			in.Path,
-			func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-				if info.IsDir() {
-					return nil
-				}
-				out = append(
-					out,
-					&FileRec{path, info.Size()},
-				)
-				return nil
-			},
-		); err != nil {
-			return nil, err
-		}
-
-		// Pretty-print the JSON for easier diff-ing
-		return json.MarshalIndent(out, "", "\t")
+		return DirSnapshot(in.Path)
 	})
 }
+
+// TestRunDirs runs agenda tests against several
+// directories at once with shared options
+func TestRunDirs(t *testing.T) {
+	RunDirs(t, []string{"testdata/01/default", "testdata/01/custom-serializer"}, test01,
+		BinarySerializer())
+}
+
+// TestRunRecursive runs agenda tests against a directory tree;
+// each subdirectory becomes a nested subtest
+func TestRunRecursive(t *testing.T) {
+	Run(t, "testdata/recursive", test01, Recursive())
+}
@@ -0,0 +1,71 @@
+package agenda
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// Timeout allows you to limit the execution time of the test function
+// for each case. A case which doesn't finish in time fails with the stack
+// dump of all goroutines, instead of letting one hung case consume
+// the entire `go test` deadline. Note that the test function can't be
+// interrupted, so its goroutine keeps running in the background.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Timeout(10*time.Second))
+func Timeout(d time.Duration) option {
+	return func(o *optionSet) {
+		o.timeout = d
+	}
+}
+
+type timeoutError struct {
+	timeout time.Duration
+	stack   []byte
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("timed out after %v", e.timeout)
+}
+
+// callTestTimeout is an internal function that calls the test function
+// (see callTest), failing if it doesn't finish within the timeout
+func callTestTimeout(test Test, path string, data []byte, opt *optionSet) ([]byte, error) {
+	if opt.timeout <= 0 {
+		return callTest(test, path, data)
+	}
+
+	type result struct {
+		output []byte
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := callTest(test, path, data)
+		done <- result{output, err}
+	}()
+
+	timer := time.NewTimer(opt.timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.output, r.err
+	case <-timer.C:
+		return nil, &timeoutError{opt.timeout, allStacks()}
+	}
+}
+
+// allStacks returns the stack traces of all goroutines
+func allStacks() []byte {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,41 @@
+package agenda
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTimeout is a traditional (non agenda-based) test
+// that checks that hung cases fail with a stack dump
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	hung := func(path string, data []byte) ([]byte, error) {
+		<-release
+		return nil, nil
+	}
+	c := &testCase{path: "testdata/shadow/1.json", resultPath: "testdata/shadow/1.json.result"}
+
+	opt := newOptionSet([]option{Timeout(10 * time.Millisecond)})
+	opt.initMode = false
+
+	var r fakeReporter
+	processFile(&r, c, hung, opt)
+	if len(r.errors) != 1 {
+		t.Fatalf("Expected 1 error, got %v", r.errors)
+	}
+	if !strings.Contains(r.errors[0], "timed out after 10ms") {
+		t.Errorf("Unexpected error: %s", r.errors[0])
+	}
+	if !strings.Contains(r.errors[0], "goroutine ") {
+		t.Errorf("Expected the error to contain the stack dump, got %s", r.errors[0])
+	}
+
+	r = fakeReporter{}
+	processFile(&r, c, test01, opt)
+	if len(r.errors) != 0 {
+		t.Errorf("Expected the case to pass, got %v", r.errors)
+	}
+}
@@ -0,0 +1,54 @@
+package agenda
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Timing enables logging of the total execution time of the test function
+// and the `n` slowest cases at the end of the run, to help pruning
+// or optimizing corpora where a handful of cases dominate the runtime.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Timing(10))
+func Timing(n int) option {
+	return func(o *optionSet) {
+		o.slowest = n
+	}
+}
+
+// reportTiming is an internal function that logs the timing statistics
+func reportTiming(t *testing.T, cases []*testCase, opt *optionSet) {
+	logf(t, opt, logAlways, "%s", timingReport(cases, opt.slowest))
+}
+
+// timingReport is an internal function that renders the total
+// execution time and the list of `n` slowest cases
+func timingReport(cases []*testCase, n int) string {
+	var ran []*testCase
+	var total time.Duration
+	for _, c := range cases {
+		if c.ran {
+			ran = append(ran, c)
+			total += c.duration
+		}
+	}
+
+	sort.SliceStable(ran, func(i, j int) bool {
+		return ran[i].duration > ran[j].duration
+	})
+	if len(ran) > n {
+		ran = ran[:n]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Total time: %v; %d slowest cases:", total, len(ran))
+	for _, c := range ran {
+		fmt.Fprintf(&b, "\n%12v  %s", c.duration, c.path)
+	}
+	return b.String()
+}
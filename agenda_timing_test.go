@@ -0,0 +1,29 @@
+package agenda
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTimingReport is a traditional (non agenda-based) test
+// that checks the rendering of the timing statistics
+func TestTimingReport(t *testing.T) {
+	cases := []*testCase{
+		{path: "a.json", ran: true, duration: 20 * time.Millisecond},
+		{path: "b.json", ran: true, duration: 3 * time.Second},
+		{path: "c.json"},
+		{path: "d.json", ran: true, duration: 150 * time.Millisecond},
+	}
+
+	want := "Total time: 3.17s; 2 slowest cases:" +
+		"\n          3s  b.json" +
+		"\n       150ms  d.json"
+	if got := timingReport(cases, 2); got != want {
+		t.Errorf("Unexpected report:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestTiming runs agenda tests with the timing statistics enabled
+func TestTiming(t *testing.T) {
+	Run(t, "testdata/recursive", test01, Recursive(), Timing(3))
+}
@@ -0,0 +1,73 @@
+package agenda
+
+import (
+	"context"
+	"strings"
+)
+
+// Tracer defines the interface of the tracer which agenda uses to emit
+// a span per case under a span of the whole run. It mirrors the shape
+// of the OpenTelemetry tracer, so that an adapter is a few lines of code
+// and agenda doesn't depend on a particular tracing library:
+//
+//		type otelTracer struct{ trace.Tracer }
+//
+//		func (t otelTracer) Start(ctx context.Context, name string) (context.Context, agenda.Span) {
+//			ctx, span := t.Tracer.Start(ctx, name)
+//			return ctx, otelSpan{span}
+//		}
+type Tracer interface {
+	// Start starts the span as a child of the span in the context
+	// and returns the context holding the new span
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span defines the interface of the span started by the Tracer
+type Span interface {
+	// SetAttribute sets the attribute of the span; the value
+	// is a string, an int or a bool
+	SetAttribute(key string, value interface{})
+	// End completes the span
+	End()
+}
+
+// Trace makes agenda emit a span per case (with the "agenda.file",
+// "agenda.size", "agenda.result" and "agenda.diff_length" attributes)
+// under the span of the run named after the test, so that slow snapshot
+// suites can be analyzed in tracing backends alongside other build
+// telemetry. The diff length is the total length of the failure messages
+// of the case, which include the diffs. The span of the run is started
+// as a child of the span in `ctx`, which can be nil.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.Trace(ctx, otelTracer{tracer}))
+func Trace(ctx context.Context, tracer Tracer) option {
+	return func(o *optionSet) {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		o.traceCtx = ctx
+		o.tracer = tracer
+	}
+}
+
+// endCaseSpan is an internal function that sets
+// the attributes of the case span and completes it
+func endCaseSpan(span Span, c *testCase, opt *optionSet) {
+	span.SetAttribute("agenda.file", c.path)
+	span.SetAttribute("agenda.size", c.size)
+	span.SetAttribute("agenda.result", string(c.status(opt)))
+	span.SetAttribute("agenda.diff_length", len(strings.Join(c.failures, "\n")))
+	span.End()
+}
+
+// endRunSpan is an internal function that sets
+// the attributes of the run span and completes it
+func endRunSpan(span Span, summary Summary) {
+	span.SetAttribute("agenda.total", summary.Total)
+	span.SetAttribute("agenda.passed", summary.Passed)
+	span.SetAttribute("agenda.failed", summary.Failed)
+	span.SetAttribute("agenda.initialized", summary.Initialized)
+	span.End()
+}
@@ -0,0 +1,79 @@
+package agenda
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// recordingTracer records the spans it starts
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+type parentKey struct{}
+
+func (r *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(parentKey{}).(string)
+	span := &recordingSpan{name: name, parent: parent, attrs: make(map[string]interface{})}
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, parentKey{}, name), span
+}
+
+type recordingSpan struct {
+	name, parent string
+	attrs        map[string]interface{}
+	ended        bool
+}
+
+func (s *recordingSpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *recordingSpan) End() {
+	s.ended = true
+}
+
+func (s *recordingSpan) String() string {
+	var attrs []string
+	for key, value := range s.attrs {
+		attrs = append(attrs, fmt.Sprintf("%s=%v", key, value))
+	}
+	sort.Strings(attrs)
+	return fmt.Sprintf("%s<%s %s", s.name, s.parent, strings.Join(attrs, " "))
+}
+
+// TestTrace is a traditional (non agenda-based) test
+// that checks the spans emitted for the run and its cases
+func TestTrace(t *testing.T) {
+	tracer := &recordingTracer{}
+	// failures are reported in shadow mode, so that this test doesn't fail
+	Run(t, "testdata/shadow", test01, Shadow(nil), Trace(context.Background(), tracer))
+
+	var got []string
+	for _, s := range tracer.spans {
+		if !s.ended {
+			t.Errorf("Span %s is not ended", s.name)
+		}
+		got = append(got, s.String())
+	}
+	want := []string{
+		"TestTrace< agenda.failed=2 agenda.initialized=0 agenda.passed=1 agenda.total=3",
+		"1.json<TestTrace agenda.diff_length=0 agenda.file=testdata/shadow/1.json agenda.result=passed agenda.size=19",
+		"2.json<TestTrace agenda.diff_length=",
+		"3.json<TestTrace agenda.diff_length=",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Unexpected spans:\n%s", strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("Expected span %q, got %q", want[i], got[i])
+		}
+	}
+	if !strings.HasSuffix(got[3], "agenda.file=testdata/shadow/3.json agenda.result=failed agenda.size=20") {
+		t.Errorf("Unexpected span: %s", got[3])
+	}
+}
@@ -0,0 +1,94 @@
+package agenda
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// TransformInput adds a function which transforms the contents of each
+// input data file before it is passed to the test function, to preprocess
+// the whole corpus uniformly (e.g. decompress the inputs, strip comments
+// or inject default values). Transformations are applied in the order
+// the options are specified.
+//
+// Example:
+//
+//		agenda.Run(t, "./testdata/mytest", testFunc, agenda.TransformInput(func(path string, data []byte) ([]byte, error) {
+//			return gunzip(data)
+//		}))
+func TransformInput(f func(path string, data []byte) ([]byte, error)) option {
+	if f == nil {
+		panic("transform function is nil")
+	}
+	return func(o *optionSet) {
+		o.inputTransforms = append(o.inputTransforms, f)
+	}
+}
+
+// TransformOutput adds a function which transforms the output of the test
+// function before it is compared with the reference data or saved as the new
+// reference, so that the normalization of the output (e.g. sorting,
+// pretty-printing or scrubbing the volatile values) can be configured once
+// instead of inside every test function. Transformations are applied
+// in the order the options are specified.
+//
+// Example:
+//
+//		agenda.Run(t, "./testdata/mytest", testFunc, agenda.TransformOutput(func(path string, data []byte) ([]byte, error) {
+//			var buf bytes.Buffer
+//			err := json.Indent(&buf, data, "", "\t")
+//			return buf.Bytes(), err
+//		}))
+func TransformOutput(f func(path string, data []byte) ([]byte, error)) option {
+	if f == nil {
+		panic("transform function is nil")
+	}
+	return func(o *optionSet) {
+		o.outputTransforms = append(o.outputTransforms, f)
+	}
+}
+
+// transformInput is an internal function that applies all configured
+// input transformations to the input data
+func transformInput(path string, data []byte, opt *optionSet) ([]byte, error) {
+	for _, f := range opt.inputTransforms {
+		var err error
+		if data, err = f(path, data); err != nil {
+			return nil, fmt.Errorf("can't transform the input: %v", err)
+		}
+	}
+	return data, nil
+}
+
+// transformOutput is an internal function that applies all configured
+// output transformations to the generated output
+func transformOutput(path string, output []byte, opt *optionSet) ([]byte, error) {
+	for _, f := range opt.outputTransforms {
+		var err error
+		if output, err = f(path, output); err != nil {
+			return nil, err
+		}
+	}
+	return mask(output, opt), nil
+}
+
+// PrettyJSON is a shortcut option that pretty-prints the JSON output
+// of the test function (indented with tabs, with a trailing newline)
+// before it is compared with the reference data or saved, so that
+// the result files are reviewable and the diffs are line-oriented
+// without every test function having to indent its output.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.PrettyJSON())
+func PrettyJSON() option {
+	return TransformOutput(func(path string, data []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", "\t"); err != nil {
+			return nil, fmt.Errorf("can't pretty-print the output: %v", err)
+		}
+		buf.WriteByte('\n')
+		return buf.Bytes(), nil
+	})
+}
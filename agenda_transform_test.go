@@ -0,0 +1,78 @@
+package agenda
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stripHashComments is a sample input transformation which removes
+// the lines starting with '#' and sets the default value of "c"
+func stripHashComments(path string, data []byte) ([]byte, error) {
+	var lines [][]byte
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if !bytes.HasPrefix(line, []byte("#")) {
+			lines = append(lines, line)
+		}
+	}
+
+	in := map[string]interface{}{"c": 10}
+	if err := json.Unmarshal(bytes.Join(lines, []byte("\n")), &in); err != nil {
+		return nil, err
+	}
+	return json.Marshal(in)
+}
+
+// TestTransformInput runs agenda tests with preprocessed inputs
+func TestTransformInput(t *testing.T) {
+	Run(t, "testdata/transform-input", test01, TransformInput(stripHashComments))
+}
+
+// TestTransformInputError is a traditional (non agenda-based) test
+// that checks that transformation errors fail the case
+func TestTransformInputError(t *testing.T) {
+	failing := TransformInput(func(path string, data []byte) ([]byte, error) {
+		return nil, errors.New("broken input")
+	})
+
+	c := &testCase{path: "testdata/transform-input/1.json"}
+	_, err := c.readInput(newOptionSet([]option{failing}))
+	if err == nil || !strings.Contains(err.Error(), "broken input") {
+		t.Errorf("Expected the transformation error, got %v", err)
+	}
+}
+
+// indentJSON is a sample output transformation which pretty-prints JSON
+func indentJSON(path string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "\t"); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// TestTransformOutput runs agenda tests with postprocessed outputs
+func TestTransformOutput(t *testing.T) {
+	Run(t, "testdata/transform-output", test01, TransformOutput(indentJSON))
+}
+
+// TestTransformOutputError is a traditional (non agenda-based) test
+// that checks that transformation errors fail the case
+func TestTransformOutputError(t *testing.T) {
+	r := &fakeReporter{}
+	c := &testCase{path: "testdata/transform-output/1.json", resultPath: "testdata/transform-output/1.json.result"}
+	processFile(r, c, func(path string, data []byte) ([]byte, error) {
+		return []byte("not JSON"), nil
+	}, newOptionSet([]option{TransformOutput(indentJSON)}))
+
+	if len(r.errors) != 1 || !strings.HasPrefix(r.errors[0], "Can't transform the output") {
+		t.Errorf("Expected the transformation error, got %v", r.errors)
+	}
+}
+
+// TestPrettyJSON runs agenda tests whose output is pretty-printed
+func TestPrettyJSON(t *testing.T) {
+	Run(t, "testdata/pretty-json", test01, PrettyJSON())
+}
@@ -0,0 +1,164 @@
+package agenda
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// TreeTest defines the callback function of an agenda test which generates
+// multiple files per case: it takes the contents of the test data file,
+// and writes the generated files into the `outDir` directory
+type TreeTest func(path string, data []byte, outDir string) error
+
+// RunTree executes an agenda test function which generates directory trees
+// (e.g. a code generator emitting multiple files per case) against all
+// the input data files in the directory. Each case gets a new empty output
+// directory, and the whole generated tree (file names and contents) is
+// compared with the golden tree stored in the directory named like the result
+// file of the case (e.g. "01.json.result/"); added, removed and changed files
+// are reported. In initialization mode, the golden tree is updated to match
+// the generated one. Cases which generate no files need no golden directory.
+// The options which track the outcome of the cases across the run
+// (e.g. Observe(), History() or Cache()) are not supported.
+//
+// Example:
+//
+//		agenda.RunTree(t, "./testdata/mytest", func(path string, data []byte, outDir string) error {
+//			return generator.Generate(data, outDir)
+//		})
+func RunTree(t *testing.T, dir string, test TreeTest, options ...option) {
+	if test == nil {
+		panic("test function is nil")
+	}
+
+	opt := newOptionSet(options)
+	rejectRunOptions(t, "RunTree", opt)
+	opt.roots = append(opt.roots, dir)
+
+	cases := selectCases(t, findCases(t, dir, opt), opt)
+
+	runNested(t, cases, 0, func(t *testing.T, c *testCase) {
+		logf(t, opt, logInfo, "%s", c.path)
+		input, err := c.readInput(opt)
+		if err != nil {
+			t.Errorf("Can't read the file: %v", err)
+			return
+		}
+
+		outDir, err := ioutil.TempDir("", "agenda-tree")
+		if err != nil {
+			t.Fatalf("Can't create the output directory: %v", err)
+		}
+		defer os.RemoveAll(outDir)
+
+		_, err = callTestTimeout(func(path string, data []byte) ([]byte, error) {
+			return nil, test(path, data, outDir)
+		}, c.path, input, opt)
+		if err != nil {
+			t.Errorf("Error during test() call: %v", describeError(err))
+			return
+		}
+
+		checkTree(t, c.resultPath, outDir, opt)
+	})
+}
+
+// checkTree is an internal function that compares the generated tree
+// with the golden one in test mode, or updates the golden tree in init mode
+func checkTree(t reporter, goldenDir, outDir string, opt *optionSet) {
+	registerTree(goldenDir)
+	got, err := readTree(outDir)
+	if err != nil {
+		t.Errorf("Can't read the generated files: %v", err)
+		return
+	}
+	for name, output := range got {
+		path := filepath.Join(goldenDir, filepath.FromSlash(name))
+		if got[name], err = transformOutput(path, output, opt); err != nil {
+			t.Errorf("Can't transform the generated file '%s': %v", name, err)
+			return
+		}
+	}
+
+	want, err := readTree(goldenDir)
+	if os.IsNotExist(err) && (opt.initMode || len(got) == 0) {
+		// the golden directory of an empty tree doesn't exist,
+		// as Git doesn't keep empty directories
+		want, err = nil, nil
+	}
+	if os.IsNotExist(err) {
+		t.Errorf("Directory '%s' doesn't exist (try initializing snapshots with 'go test -args init')", goldenDir)
+		return
+	}
+	if err != nil {
+		t.Errorf("Can't read the golden tree: %v", err)
+		return
+	}
+
+	names := make([]string, 0, len(got)+len(want))
+	for name := range got {
+		names = append(names, name)
+	}
+	for name := range want {
+		if _, ok := got[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(goldenDir, filepath.FromSlash(name))
+		output, generated := got[name]
+		reference, exists := want[name]
+
+		if opt.initMode {
+			if !generated {
+				logf(t, opt, logInfo, "Removing file '%s'", path)
+				if err := removeFile(path, opt); err != nil {
+					t.Errorf("Can't remove the stale '%s' file: %v", path, err)
+				}
+				continue
+			}
+			checkResult(t, path, output, opt)
+			continue
+		}
+
+		switch {
+		case !exists:
+			t.Errorf("File '%s' was generated, but is not present in the golden tree '%s'", name, goldenDir)
+		case !generated:
+			t.Errorf("File '%s' of the golden tree '%s' was not generated", name, goldenDir)
+		default:
+			compareFile(t, path, nil, reference, output, opt)
+		}
+	}
+}
+
+// readTree is an internal function that reads all files in the directory
+// into the map keyed by their slash-separated relative paths
+func readTree(dir string) (map[string][]byte, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	return files, err
+}
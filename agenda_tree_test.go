@@ -0,0 +1,114 @@
+package agenda
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTree is a sample test callback function which writes
+// the files listed in the input data
+func testTree(path string, data []byte, outDir string) error {
+	in := struct {
+		Files map[string]string `json:"files"`
+	}{}
+
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	for name, content := range in.Files {
+		p := filepath.Join(outDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestRunTree runs agenda tests which generate directory trees
+func TestRunTree(t *testing.T) {
+	RunTree(t, "testdata/tree", testTree)
+}
+
+// TestCheckTree is a traditional (non agenda-based) test
+// that checks the reported differences between the trees
+func TestCheckTree(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outDir)
+
+	err = testTree("", []byte(`{"files": {"main.go": "package changed\n", "extra.go": "package extra\n"}}`), outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opt := newOptionSet(nil)
+	opt.initMode = false
+
+	var r fakeReporter
+	checkTree(&r, "testdata/tree/1.json.result", outDir, opt)
+
+	if len(r.errors) != 3 {
+		t.Fatalf("Expected 3 errors, got %d: %v", len(r.errors), r.errors)
+	}
+	for i, prefix := range []string{
+		"File 'extra.go' was generated, but is not present",
+		"Reference testdata/tree/1.json.result/main.go contents don't match",
+		"File 'sub/util.go' of the golden tree",
+	} {
+		if !strings.HasPrefix(r.errors[i], prefix) {
+			t.Errorf("Expected error %d to start with %q, got %q", i, prefix, r.errors[i])
+		}
+	}
+}
+
+// TestCheckEmptyTree is a traditional (non agenda-based) test that checks
+// that an empty generated tree matches the missing golden directory
+func TestCheckEmptyTree(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outDir)
+
+	opt := newOptionSet(nil)
+	opt.initMode = false
+
+	var r fakeReporter
+	checkTree(&r, filepath.Join(outDir, "missing.json.result"), outDir, opt)
+	if len(r.errors) != 0 {
+		t.Errorf("Expected the empty tree to match, got %v", r.errors)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(outDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(&r, filepath.Join(outDir, "missing.json.result"), outDir, opt)
+	if len(r.errors) != 1 || !strings.HasPrefix(r.errors[0], "Directory ") {
+		t.Errorf("Expected the missing golden directory to be reported, got %v", r.errors)
+	}
+}
+
+// TestCaseTrackingOptions is a traditional (non agenda-based) test that
+// checks which options are rejected by the runners which don't track
+// the outcome of the cases (e.g. RunTree)
+func TestCaseTrackingOptions(t *testing.T) {
+	if names := caseTrackingOptions(newOptionSet([]option{Timeout(time.Second), Mask("a", "b")})); len(names) != 0 {
+		t.Errorf("Expected no options to be rejected, got %v", names)
+	}
+
+	opt := newOptionSet([]option{TAP(ioutil.Discard), Cache("cache.json", "v1"), RetryFailedCases(1)})
+	if got, want := strings.Join(caseTrackingOptions(opt), ", "), "Observe() or TAP(), Cache(), RetryFailedCases()"; got != want {
+		t.Errorf("Expected %q to be rejected, got %q", want, got)
+	}
+}
@@ -0,0 +1,174 @@
+package agenda
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// RunTxtar executes an agenda test function against all the txtar (".txtar")
+// files in the directory, where each file holds both the input sections and
+// the expected output section, so that each case is fully self-contained
+// and easy to review. The test function receives the contents of all sections
+// except the output one, keyed by their names. The output section is named
+// after the result suffix without the leading dot ("result" by default);
+// in initialization mode, only this section is rewritten (or appended),
+// keeping the comment and the input sections intact. As the txtar format
+// requires each section to end with a newline, one is appended to the generated
+// output if it's missing. The options which track the outcome of the cases
+// across the run (e.g. Observe(), History() or Cache()) are not supported.
+//
+// Example:
+//
+//		// 01.txtar:
+//		// -- request.json --
+//		// {"a":1,"b":2}
+//		// -- result --
+//		// {"result":3}
+//		agenda.RunTxtar(t, "./testdata/mytest", func(path string, files map[string][]byte) ([]byte, error) {
+//			return handle(files["request.json"])
+//		})
+func RunTxtar(t *testing.T, dir string, test GroupTest, options ...option) {
+	if test == nil {
+		panic("test function is nil")
+	}
+
+	opt := newOptionSet(append([]option{FileSuffix(".txtar")}, options...))
+	rejectRunOptions(t, "RunTxtar", opt)
+	opt.roots = append(opt.roots, dir)
+	section := strings.TrimPrefix(opt.resultSuffix, ".")
+
+	cases := selectCases(t, findCases(t, dir, opt), opt)
+
+	runNested(t, cases, 0, func(t *testing.T, c *testCase) {
+		logf(t, opt, logInfo, "%s", c.path)
+		input, err := c.readRawInput(opt)
+		if err != nil {
+			t.Errorf("Can't read the file: %v", err)
+			return
+		}
+
+		// input transformations are applied to the sections passed
+		// to the test function, so that init mode keeps them intact
+		comment, sections := parseTxtar(input)
+		files := make(map[string][]byte)
+		var reference []byte
+		hasReference := false
+		for _, s := range sections {
+			if s.name == section {
+				reference, hasReference = s.data, true
+				continue
+			}
+			if files[s.name], err = transformInput(c.path, s.data, opt); err != nil {
+				t.Errorf("Can't read the '%s' section: %v", s.name, err)
+				return
+			}
+		}
+
+		output, err := callTestTimeout(func(path string, data []byte) ([]byte, error) {
+			return test(path, files)
+		}, c.path, input, opt)
+		if err != nil {
+			t.Errorf("Error during test() call: %v", describeError(err))
+			return
+		}
+		output, err = transformOutput(c.path, output, opt)
+		if err != nil {
+			t.Errorf("Can't transform the output: %v", err)
+			return
+		}
+		if len(output) > 0 && output[len(output)-1] != '\n' {
+			output = append(output, '\n')
+		}
+
+		if !opt.initMode {
+			if !hasReference {
+				t.Errorf("File '%s' has no '%s' section (try initializing snapshots with 'go test -args init')", c.path, section)
+				return
+			}
+			compareOutput(t, c.path+"#"+section, reference, output, opt)
+			return
+		}
+
+		replaced := false
+		for i := range sections {
+			if sections[i].name == section {
+				sections[i].data, replaced = output, true
+			}
+		}
+		if !replaced {
+			sections = append(sections, txtarSection{section, output})
+		}
+		checkResult(t, c.path, formatTxtar(comment, sections), opt)
+	})
+}
+
+// txtarSection is a named section of the txtar file
+type txtarSection struct {
+	name string
+	data []byte
+}
+
+// parseTxtar is an internal function that parses the txtar file
+// into the leading comment and the sections
+func parseTxtar(data []byte) ([]byte, []txtarSection) {
+	var sections []txtarSection
+	var name string
+	comment, data := cutTxtarSection(data, &name)
+	for name != "" {
+		s := txtarSection{name: name}
+		s.data, data = cutTxtarSection(data, &name)
+		sections = append(sections, s)
+	}
+	return comment, sections
+}
+
+// cutTxtarSection is an internal function that returns the data before
+// the next section marker and the data after it, setting the `name`
+// to the name of the next section (or to the empty string if there is none)
+func cutTxtarSection(data []byte, name *string) ([]byte, []byte) {
+	for i := 0; i < len(data); {
+		end := bytes.IndexByte(data[i:], '\n')
+		line := data[i:]
+		if end >= 0 {
+			line = data[i : i+end+1]
+		}
+		if n := txtarMarker(line); n != "" {
+			*name = n
+			return data[:i], data[i+len(line):]
+		}
+		i += len(line)
+	}
+	*name = ""
+	return data, nil
+}
+
+// txtarMarker is an internal function that returns the section name
+// if the line is a section marker ("-- name --")
+func txtarMarker(line []byte) string {
+	s := strings.TrimRight(string(line), "\r\n")
+	if !strings.HasPrefix(s, "-- ") || !strings.HasSuffix(s, " --") || len(s) < 7 {
+		return ""
+	}
+	return strings.TrimSpace(s[3 : len(s)-3])
+}
+
+// formatTxtar is an internal function that renders the txtar file
+func formatTxtar(comment []byte, sections []txtarSection) []byte {
+	var buf bytes.Buffer
+	buf.Write(withNewline(comment))
+	for _, s := range sections {
+		buf.WriteString("-- " + s.name + " --\n")
+		buf.Write(withNewline(s.data))
+	}
+	return buf.Bytes()
+}
+
+// withNewline is an internal function that appends the newline
+// to the non-empty data that doesn't end with one
+func withNewline(data []byte) []byte {
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		return append(data[:len(data):len(data)], '\n')
+	}
+	return data
+}
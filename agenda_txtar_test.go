@@ -0,0 +1,77 @@
+package agenda
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testTxtar is a sample test callback function which sums the numbers
+// from the request, multiplied by the factor from the config
+func testTxtar(path string, files map[string][]byte) ([]byte, error) {
+	var req struct{ A, B, C float64 }
+	var config struct{ Factor float64 }
+
+	if err := json.Unmarshal(files["request.json"], &req); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(files["config.json"], &config); err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%v", (req.A+req.B+req.C)*config.Factor)), nil
+}
+
+// TestRunTxtar runs agenda tests stored in txtar files
+func TestRunTxtar(t *testing.T) {
+	RunTxtar(t, "testdata/txtar", testTxtar)
+}
+
+// TestRunTxtarInitTransforms is a traditional (non agenda-based) test
+// that checks that input transformations are applied to the sections
+// passed to the test function, but not to the rewritten file
+func TestRunTxtarInitTransforms(t *testing.T) {
+	dir := copyDir(t, "testdata/txtar")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "1.txtar")
+
+	input := "-- request.json --\n{\"a\":1,\"b\":2,\"c\":3} // note\n-- config.json --\n{\"factor\":10}\n"
+	if err := ioutil.WriteFile(path, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	RunTxtar(t, dir, testTxtar, InitMode(true), JSONC())
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != input+"-- result --\n60\n" {
+		t.Errorf("Expected only the result section to be added, got:\n%s", data)
+	}
+}
+
+// TestParseTxtar is a traditional (non agenda-based) test
+// that checks that parsing and formatting txtar files round-trips
+func TestParseTxtar(t *testing.T) {
+	var tests = []string{
+		"",
+		"comment only\n",
+		"comment\n-- a --\nA\n-- b --\n",
+		"-- a --\n-- not a marker\n--  --\n-- b --\nB\n",
+	}
+
+	for _, test := range tests {
+		comment, sections := parseTxtar([]byte(test))
+		if got := string(formatTxtar(comment, sections)); got != test {
+			t.Errorf("Expected %q, got %q", test, got)
+		}
+	}
+
+	_, sections := parseTxtar([]byte("-- a --\nA\n-- b --\nB"))
+	if len(sections) != 2 || sections[0].name != "a" || string(sections[1].data) != "B" {
+		t.Errorf("Unexpected sections: %q", sections)
+	}
+}
@@ -0,0 +1,64 @@
+package agenda
+
+import (
+	"bytes"
+	"errors"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// UnicodeInput enables the normalization of the input data files exported
+// by tools which don't produce plain UTF-8 (e.g. on Windows): the UTF-8
+// byte order mark is removed, and UTF-16 files (with a byte order mark,
+// or starting with an ASCII character) are decoded to UTF-8 before the data
+// is passed to the test function.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.UnicodeInput())
+func UnicodeInput() option {
+	return TransformInput(func(path string, data []byte) ([]byte, error) {
+		return decodeUnicode(data)
+	})
+}
+
+// decodeUnicode is an internal function that converts
+// the data to UTF-8 without a byte order mark
+func decodeUnicode(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return data[3:], nil
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16(data[2:], false)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16(data[2:], true)
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0 && data[1] < utf8.RuneSelf:
+		return decodeUTF16(data, true)
+	case len(data) >= 2 && data[0] != 0 && data[0] < utf8.RuneSelf && data[1] == 0:
+		return decodeUTF16(data, false)
+	}
+	return data, nil
+}
+
+// decodeUTF16 is an internal function that decodes
+// the UTF-16 data with the specified byte order to UTF-8
+func decodeUTF16(data []byte, bigEndian bool) ([]byte, error) {
+	if len(data)%2 != 0 {
+		return nil, errors.New("UTF-16 data has an odd number of bytes")
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, r := range utf16.Decode(units) {
+		buf.WriteRune(r)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,41 @@
+package agenda
+
+import (
+	"testing"
+)
+
+// TestDecodeUnicode is a traditional (non agenda-based) test
+// that decodes the data in different encodings
+func TestDecodeUnicode(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected string
+	}{
+		{[]byte(`{"a":"ü"}`), `{"a":"ü"}`},
+		{[]byte("\xEF\xBB\xBF{}"), `{}`},
+		{[]byte("\xFF\xFE{\x00}\x00"), `{}`},
+		{[]byte("\xFE\xFF\x00{\x00}"), `{}`},
+		{[]byte("{\x00\xFC\x00}\x00"), `{ü}`},
+		{[]byte("\x00{\xD8\x3D\xDE\x00\x00}"), "{\U0001F600}"},
+	}
+	for _, test := range tests {
+		out, err := decodeUnicode(test.data)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", test.data, err)
+			continue
+		}
+		if string(out) != test.expected {
+			t.Errorf("Expected %q for %q, got %q", test.expected, test.data, out)
+		}
+	}
+
+	if _, err := decodeUnicode([]byte("\xFF\xFE{")); err == nil {
+		t.Error("Expected an error for truncated UTF-16 data")
+	}
+}
+
+// TestUnicodeInput runs agenda tests against the input data files
+// in the UTF-16 encoding
+func TestUnicodeInput(t *testing.T) {
+	Run(t, "testdata/unicode-input", test01, UnicodeInput())
+}
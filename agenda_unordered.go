@@ -0,0 +1,112 @@
+package agenda
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// UnorderedArrays makes JSON arrays compare as multisets, for the outputs
+// whose element order is nondeterministic (e.g. map iteration or concurrent
+// producers). If no paths are specified, the order of all arrays is ignored;
+// otherwise, only the arrays at the specified paths are affected. Paths use
+// the same notation as JSONDiff() reports, with `[]` matching any element
+// of an array ("items", "items[].tags"; the root array is ""). Both sides
+// are re-encoded with the arrays sorted before they are compared, so that
+// the diff shows the matching elements side by side. Outputs which are not
+// valid JSON are compared as is, and result files are saved as is.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.UnorderedArrays("users", "users[].roles"))
+func UnorderedArrays(paths ...string) option {
+	return func(o *optionSet) {
+		o.normalizers = append(o.normalizers, func(reference, output []byte) ([]byte, []byte) {
+			r, err := sortJSONArrays(reference, paths)
+			if err != nil {
+				return reference, output
+			}
+			g, err := sortJSONArrays(output, paths)
+			if err != nil {
+				return reference, output
+			}
+			return r, g
+		})
+	}
+}
+
+// sortJSONArrays is an internal function that decodes the JSON document,
+// sorts the unordered arrays in it and encodes it back in a canonical form
+func sortJSONArrays(data []byte, paths []string) ([]byte, error) {
+	v, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return encodeCanonical(sortArrays("", v, paths), "\t")
+}
+
+// sortArrays is an internal function that recursively sorts
+// the elements of the unordered arrays by their canonical encoding
+func sortArrays(path string, v interface{}, paths []string) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, e := range x {
+			x[k] = sortArrays(jsonKeyPath(path, k), e, paths)
+		}
+
+	case []interface{}:
+		for i, e := range x {
+			x[i] = sortArrays(path+"[]", e, paths)
+		}
+		if !unorderedPath(path, paths) {
+			break
+		}
+
+		keys := make([]string, len(x))
+		for i, e := range x {
+			data, _ := encodeCanonical(e, "")
+			keys[i] = string(data)
+		}
+		index := make([]int, len(x))
+		for i := range index {
+			index[i] = i
+		}
+		sort.SliceStable(index, func(i, j int) bool {
+			return keys[index[i]] < keys[index[j]]
+		})
+
+		sorted := make([]interface{}, len(x))
+		for i, j := range index {
+			sorted[i] = x[j]
+		}
+		return sorted
+	}
+	return v
+}
+
+// unorderedPath is an internal function that returns true
+// if the array at the path is unordered (see UnorderedArrays())
+func unorderedPath(path string, paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeCanonical is an internal function that encodes the decoded
+// JSON value with sorted object keys and without HTML escaping
+func encodeCanonical(v interface{}, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,41 @@
+package agenda
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestUnorderedArrays is a traditional (non agenda-based) test that
+// compares JSON outputs whose arrays differ in the order of elements
+func TestUnorderedArrays(t *testing.T) {
+	reference := []byte(`{"users": [{"name": "a", "roles": ["x", "y"]}, {"name": "b", "roles": []}], "path": [1, 2]}`)
+	output := []byte(`{"path":[1,2],"users":[{"name":"b","roles":[]},{"name":"a","roles":["y","x"]}]}`)
+
+	var r fakeReporter
+	compareOutput(&r, "out.json.result", reference, output, newOptionSet([]option{UnorderedArrays()}))
+	if len(r.errors) != 0 {
+		t.Errorf("Expected no differences, got %v", r.errors)
+	}
+
+	compareOutput(&r, "out.json.result", reference, output,
+		newOptionSet([]option{UnorderedArrays("users", "users[].roles")}))
+	if len(r.errors) != 0 {
+		t.Errorf("Expected no differences, got %v", r.errors)
+	}
+
+	// the order of roles is still significant
+	compareOutput(&r, "out.json.result", reference, output,
+		newOptionSet([]option{UnorderedArrays("users")}))
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], `"y"`) {
+		t.Errorf("Expected 1 error about roles, got %v", r.errors)
+	}
+
+	// elements are compared as a multiset
+	output = []byte(`{"path":[2,1,1],"users":[]}`)
+	r = fakeReporter{}
+	compareOutput(&r, "out.json.result", []byte(`{"path":[1,2,2],"users":[]}`), output,
+		newOptionSet([]option{UnorderedArrays()}))
+	if len(r.errors) != 1 {
+		t.Errorf("Expected 1 error, got %v", r.errors)
+	}
+}
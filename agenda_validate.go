@@ -0,0 +1,84 @@
+package agenda
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ValidateInputs adds a function which validates each input data file
+// (after the input transformations) before any case is run, so that all
+// malformed files are reported at once instead of failing the run case
+// by case; the test fails without running any cases if validation fails.
+// Validators are applied in the order the options are specified.
+//
+// Example:
+//
+//		agenda.Run(t, "./testdata/mytest", testFunc, agenda.ValidateInputs(func(path string, data []byte) error {
+//			if !json.Valid(data) {
+//				return errors.New("not a valid JSON document")
+//			}
+//			return nil
+//		}))
+func ValidateInputs(f func(path string, data []byte) error) option {
+	if f == nil {
+		panic("validation function is nil")
+	}
+	return func(o *optionSet) {
+		o.inputValidators = append(o.inputValidators, f)
+	}
+}
+
+// InputSchema is a shortcut option that validates each input data file
+// against the JSON Schema in the file at `path` (see ValidateInputs()
+// and OutputSchema() for the supported subset of JSON Schema)
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.InputSchema("./testdata/request.schema.json"))
+func InputSchema(path string) option {
+	return ValidateInputs(func(_ string, data []byte) error {
+		violations, err := checkSchema(path, data)
+		if err != nil {
+			return err
+		}
+		if len(violations) > 0 {
+			return fmt.Errorf("doesn't match the schema '%s':\n%s", path, strings.Join(violations, "\n"))
+		}
+		return nil
+	})
+}
+
+// validateInputs is an internal function that validates the input data
+// of all cases which are not skipped, and fails the test if any of them
+// is malformed
+func validateInputs(t *testing.T, cases []*testCase, opt *optionSet) {
+	failed := false
+	for _, c := range cases {
+		if c.skip != "" {
+			continue
+		}
+		if err := validateInput(c, caseOptions(c, opt)); err != nil {
+			t.Errorf("Input file '%s' is not valid: %v", c.path, err)
+			failed = true
+		}
+	}
+	if failed {
+		t.FailNow()
+	}
+}
+
+// validateInput is an internal function that reads the input data
+// of the case and applies all configured validators to it
+func validateInput(c *testCase, opt *optionSet) error {
+	data, err := c.readInput(opt)
+	if err != nil {
+		return err
+	}
+	for _, f := range opt.inputValidators {
+		if err := f(c.path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package agenda
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validJSON is a validator which rejects malformed JSON documents
+func validJSON(path string, data []byte) error {
+	if !json.Valid(data) {
+		return errors.New("not a valid JSON document")
+	}
+	return nil
+}
+
+// TestValidateInputs runs agenda tests with the validated inputs
+func TestValidateInputs(t *testing.T) {
+	Run(t, "testdata/01/default", test01, ValidateInputs(validJSON))
+}
+
+// TestValidateInput is a traditional (non agenda-based) test
+// that checks that malformed input files are detected
+func TestValidateInput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	schema := `{"type": "object", "required": ["a"], "properties": {"a": {"type": "number"}}}`
+	files := map[string]string{
+		"1.json":       `{"a": 1}`,
+		"2.json":       `{"a": 1`,
+		"3.json":       `{"b": 1}`,
+		"input.schema": schema,
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opt := newOptionSet([]option{ValidateInputs(validJSON), InputSchema(filepath.Join(dir, "input.schema"))})
+	cases, err := listCases(dir, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(map[string]string)
+	for _, c := range cases {
+		if err := validateInput(c, opt); err != nil {
+			errs[filepath.Base(c.path)] = err.Error()
+		}
+	}
+	if len(errs) != 2 ||
+		errs["2.json"] != "not a valid JSON document" ||
+		!strings.Contains(errs["3.json"], `missing required key "a"`) {
+		t.Errorf("Unexpected validation errors: %v", errs)
+	}
+}
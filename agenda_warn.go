@@ -0,0 +1,37 @@
+package agenda
+
+// WarnOnly enables the warn-only mode for introducing snapshot coverage
+// gradually: mismatches between the generated output and the reference data
+// (including missing result files) are logged as warnings and counted in
+// the summary, but don't fail the test. Unlike Shadow(), other failures
+// (e.g. errors returned by the test function) still fail the test.
+//
+// Example:
+//
+// agenda.Run(t, "./testdata/mytest", testFunc, agenda.WarnOnly())
+func WarnOnly() option {
+	return func(o *optionSet) {
+		o.warnings = new(int)
+	}
+}
+
+// warnReporter is a reporter which logs the failures
+// reported through it as warnings
+type warnReporter struct {
+	reporter
+	opt *optionSet
+}
+
+func (r *warnReporter) Errorf(format string, args ...interface{}) {
+	*r.opt.warnings++
+	logf(r.reporter, r.opt, logAlways, "Warning: "+format, args...)
+}
+
+// mismatchReporter is an internal function that returns the reporter
+// for the mismatches of the output, which depends on the warn-only mode
+func mismatchReporter(t reporter, opt *optionSet) reporter {
+	if opt.warnings == nil {
+		return t
+	}
+	return &warnReporter{reporter: t, opt: opt}
+}
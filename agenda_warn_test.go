@@ -0,0 +1,42 @@
+package agenda
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWarnOnly is a traditional (non agenda-based) test that checks
+// that mismatches are reported as warnings in warn-only mode
+func TestWarnOnly(t *testing.T) {
+	dir := copyDir(t, "testdata/01/default")
+	defer os.RemoveAll(dir)
+
+	if err := os.Remove(filepath.Join(dir, "1.json.result")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	res := RunWithResults(t, dir, func(path string, data []byte) ([]byte, error) {
+		if strings.HasSuffix(path, "2.json") {
+			return []byte("{}"), nil
+		}
+		return test01(path, data)
+	}, WarnOnly(), Logger(log.New(&buf, "", 0)))
+
+	if res.Failed != 0 {
+		t.Errorf("Expected no failures in warn-only mode, got %+v", res.Summary)
+	}
+	for _, s := range []string{
+		"Warning: File '" + filepath.Join(dir, "1.json.result") + "' doesn't exist",
+		"Warning: Reference " + filepath.Join(dir, "2.json.result") + " contents don't match",
+		"Warn-only mode: 2 mismatches found",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("Expected %q in the log, got:\n%s", s, buf.String())
+		}
+	}
+}
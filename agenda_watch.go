@@ -0,0 +1,155 @@
+package agenda
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// watchInterval is the interval of polling the files for changes in watch mode
+var watchInterval = 500 * time.Millisecond
+
+// RunWatch is similar to Run, but when the tests are run in watch mode
+// (`go test -run TestName -args watch`), it keeps monitoring the test data
+// directory and re-runs the affected cases as soon as their input or result
+// files change, printing the diffs immediately, which makes a fast inner loop
+// for fixture-heavy development. Failures in watch mode are printed, but don't
+// fail the test. As changes in the Go source files of the package require
+// recompiling the test, watch mode ends when they are detected; run it
+// in a loop to restart it automatically:
+//
+//		while go test -run TestName -args watch; do :; done
+//
+// Example:
+//
+// agenda.RunWatch(t, "./testdata/mytest", testFunc)
+func RunWatch(t *testing.T, dir string, test Test, options ...option) {
+	if flag.Arg(0) != "watch" {
+		Run(t, dir, test, options...)
+		return
+	}
+
+	if test == nil {
+		panic("test function is nil")
+	}
+
+	opt := newOptionSet(options)
+	opt.roots = append(opt.roots, dir)
+	watch(t, dir, test, opt, nil, os.Stdout)
+}
+
+// watch is an internal function that runs the cases of the directory
+// whenever their files change, until the source files change
+// or the `stop` channel is closed
+func watch(t *testing.T, dir string, test Test, opt *optionSet, stop <-chan struct{}, w io.Writer) {
+	fmt.Fprintf(w, "Watching %s for changes\n", dir)
+
+	isSource := func(name string) bool {
+		return strings.HasSuffix(name, ".go")
+	}
+	sources := watchState(".", false, isSource)
+	var files map[string]string
+	for {
+		current := watchState(dir, opt.recursive, nil)
+		cases, err := listCases(dir, opt)
+		if err != nil {
+			t.Fatalf("Can't read the directory contents: %v", err)
+		}
+
+		for _, c := range cases {
+			if files != nil && !caseChanged(c, files, current, opt) {
+				continue
+			}
+			r := &printReporter{w: w}
+			processFile(r, c, test, opt)
+			if r.failed {
+				fmt.Fprintf(w, "FAIL %s\n", c.path)
+			} else {
+				fmt.Fprintf(w, "ok   %s\n", c.path)
+			}
+		}
+		files = current
+
+		for unchanged := true; unchanged; unchanged = sameState(files, watchState(dir, opt.recursive, nil)) {
+			select {
+			case <-stop:
+				return
+			case <-time.After(watchInterval):
+			}
+			if !sameState(sources, watchState(".", false, isSource)) {
+				t.Logf("Source files changed; restart the tests to pick up the changes")
+				return
+			}
+		}
+	}
+}
+
+// caseChanged is an internal function that returns true if any of the files
+// of the case changed between the `before` and `after` states
+func caseChanged(c *testCase, before, after map[string]string, opt *optionSet) bool {
+	for _, path := range []string{c.path, c.resultPath, c.errorPath(opt), c.path + envSuffix} {
+		if before[path] != after[path] {
+			return true
+		}
+	}
+	return false
+}
+
+// watchState is an internal function that returns the modification time
+// and size of each file in the directory matching the filter (if any)
+func watchState(dir string, recursive bool, filter func(name string) bool) map[string]string {
+	state := make(map[string]string)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filter == nil || filter(info.Name()) {
+			state[path] = fmt.Sprintf("%d %d", info.ModTime().UnixNano(), info.Size())
+		}
+		return nil
+	})
+	return state
+}
+
+// sameState is an internal function that compares two states
+func sameState(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, s := range a {
+		if b[path] != s {
+			return false
+		}
+	}
+	return true
+}
+
+// printReporter is a reporter which prints the messages to the writer immediately
+type printReporter struct {
+	w      io.Writer
+	failed bool
+}
+
+func (r *printReporter) Log(args ...interface{}) {
+	fmt.Fprintln(r.w, args...)
+}
+
+func (r *printReporter) Logf(format string, args ...interface{}) {
+	fmt.Fprintf(r.w, format+"\n", args...)
+}
+
+func (r *printReporter) Errorf(format string, args ...interface{}) {
+	r.failed = true
+	fmt.Fprintf(r.w, format+"\n", args...)
+}
@@ -0,0 +1,73 @@
+package agenda
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a buffer which is safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestWatch is a traditional (non agenda-based) test that checks
+// that only the changed cases are re-run in watch mode
+func TestWatch(t *testing.T) {
+	dir := copyDir(t, "testdata/01/default")
+	defer os.RemoveAll(dir)
+
+	defer func(d time.Duration) { watchInterval = d }(watchInterval)
+	watchInterval = 10 * time.Millisecond
+
+	var out syncBuffer
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		watch(t, dir, test01, newOptionSet(nil), stop, &out)
+		close(done)
+	}()
+
+	waitFor := func(s string) {
+		for start := time.Now(); !strings.Contains(out.String(), s); {
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("Timed out waiting for %q, got:\n%s", s, out.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitFor("ok   " + filepath.Join(dir, "4.json"))
+	if n := strings.Count(out.String(), "ok   "); n != 4 {
+		t.Errorf("Expected 4 cases to pass, got %d", n)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "2.json"), []byte(`{"a":100,"b":1,"c":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("FAIL " + filepath.Join(dir, "2.json"))
+
+	close(stop)
+	<-done
+
+	if n := strings.Count(out.String(), "ok   "); n != 4 {
+		t.Errorf("Expected unchanged cases not to be re-run, got:\n%s", out.String())
+	}
+}
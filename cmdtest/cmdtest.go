@@ -0,0 +1,160 @@
+/*
+
+Package cmdtest provides golden testing of command-line programs
+with agenda.
+
+Each input data file describes a command invocation:
+
+	{
+		"args": ["convert", "--format", "json"],
+		"stdin": "a,b\n1,2\n",
+		"env": {"LANG": "C"}
+	}
+
+The program is run with these arguments (the program name is provided
+to Test()), standard input and additional environment variables, and the
+exit code, standard output and standard error are captured as separate
+sections of the snapshot artifact:
+
+	exit code: 0
+	-- stdout --
+	[{"a":"1","b":"2"}]
+	-- stderr --
+
+Example:
+
+	func TestCLI(t *testing.T) {
+		bin, cleanup := cmdtest.Build(t, "github.com/me/tool/cmd/tool")
+		defer cleanup()
+		agenda.Run(t, "./testdata/cli", cmdtest.Test(bin))
+	}
+
+*/
+package cmdtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/iafan/agenda"
+)
+
+// Invocation describes the command invocation
+// read from the input data file
+type Invocation struct {
+	Args  []string          `json:"args"`
+	Stdin string            `json:"stdin"`
+	Env   map[string]string `json:"env"`
+	Dir   string            `json:"dir"` // relative to the input data file
+}
+
+// config is an internal structure that holds the options
+type config struct {
+	env []string
+}
+
+// option is a type of the function that can modify
+// one or more of the options in the config structure
+type option func(c *config)
+
+// Env adds the environment variables (in the "KEY=value" form)
+// to every invocation of the program
+func Env(vars ...string) option {
+	return func(c *config) {
+		c.env = append(c.env, vars...)
+	}
+}
+
+// Test returns the agenda test function which runs the program
+// as described by each input data file, and returns the exit code,
+// standard output and standard error as the snapshot artifact.
+// A program which fails to start makes the test function return an error.
+func Test(program string, options ...option) agenda.Test {
+	c := &config{}
+	for _, f := range options {
+		f(c)
+	}
+
+	return func(path string, data []byte) ([]byte, error) {
+		var in Invocation
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, err
+		}
+
+		cmd := exec.Command(program, in.Args...)
+		cmd.Stdin = bytes.NewReader([]byte(in.Stdin))
+		cmd.Dir = filepath.Join(filepath.Dir(path), in.Dir)
+
+		cmd.Env = append(os.Environ(), c.env...)
+		keys := make([]string, 0, len(in.Env))
+		for k := range in.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			cmd.Env = append(cmd.Env, k+"="+in.Env[k])
+		}
+
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+		code := 0
+		if err := cmd.Run(); err != nil {
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				return nil, err
+			}
+			code = exitErr.ExitCode()
+		}
+
+		return Serialize(code, stdout.Bytes(), stderr.Bytes()), nil
+	}
+}
+
+// Serialize renders the outcome of the invocation as the snapshot artifact.
+// Outputs which don't end with a newline are marked, as diff does.
+func Serialize(code int, stdout, stderr []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "exit code: %d\n", code)
+	for _, section := range []struct {
+		name string
+		data []byte
+	}{{"stdout", stdout}, {"stderr", stderr}} {
+		fmt.Fprintf(&buf, "-- %s --\n", section.name)
+		buf.Write(section.data)
+		if len(section.data) > 0 && section.data[len(section.data)-1] != '\n' {
+			buf.WriteString("\n\\ No newline at end of output\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+// Build compiles the main package (e.g. "github.com/me/tool/cmd/tool")
+// into a temporary directory with `go build`, and returns the path
+// of the binary, along with the function that removes the directory
+func Build(t *testing.T, pkg string) (string, func()) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "cmdtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup := func() {
+		os.RemoveAll(dir)
+	}
+
+	bin := filepath.Join(dir, filepath.Base(pkg))
+	out, err := exec.Command("go", "build", "-o", bin, pkg).CombinedOutput()
+	if err != nil {
+		cleanup()
+		t.Fatalf("Can't build '%s': %v\n%s", pkg, err, out)
+	}
+	return bin, cleanup
+}
@@ -0,0 +1,42 @@
+package cmdtest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/iafan/agenda"
+)
+
+// helperEnv makes the test binary act as a sample command-line program
+const helperEnv = "AGENDA_CMDTEST_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		os.Exit(upper(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// upper is a sample command-line program which upper-cases
+// its standard input
+func upper(args []string) int {
+	if len(args) > 0 && args[0] == "--fail" {
+		fmt.Fprintln(os.Stderr, "upper: failing as requested")
+		return 3
+	}
+
+	prefix := os.Getenv("UPPER_PREFIX")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fmt.Println(prefix + strings.ToUpper(scanner.Text()))
+	}
+	fmt.Fprint(os.Stderr, "done")
+	return 0
+}
+
+func TestCommand(t *testing.T) {
+	agenda.Run(t, "testdata/cli", Test(os.Args[0], Env(helperEnv+"=1")))
+}
@@ -0,0 +1,273 @@
+/*
+
+Package extensiontest provides a conformance test kit for user-provided
+agenda extensions: serializers, comparators and writable file systems.
+
+Each function runs a standard suite of edge cases (empty input, huge input,
+invalid UTF-8, concurrent access) as subtests of the provided test, and fails
+it when the extension doesn't meet the behavioral contract of the agenda
+package: extensions must not panic, must not modify the data passed to them,
+must be deterministic and must be safe for concurrent use.
+
+Example:
+
+	func TestMySerializer(t *testing.T) {
+		extensiontest.Serializer(t, mySerializer)
+	}
+
+*/
+package extensiontest
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/iafan/agenda"
+)
+
+// hugeSize is the size of the "huge" input used in the edge cases
+const hugeSize = 4 << 20
+
+// concurrency is the number of goroutines used to check concurrent access
+const concurrency = 8
+
+// input describes a single edge case
+type input struct {
+	name string
+	data []byte
+}
+
+// inputs returns the standard set of edge cases
+func inputs() []input {
+	huge := make([]byte, hugeSize)
+	for i := range huge {
+		huge[i] = byte('a' + i%26)
+		if i%80 == 79 {
+			huge[i] = '\n'
+		}
+	}
+
+	return []input{
+		{"empty", []byte{}},
+		{"nil", nil},
+		{"text", []byte("{\"result\":3}\n")},
+		{"no trailing newline", []byte("line 1\nline 2")},
+		{"invalid UTF-8", []byte("valid \xff\xfe invalid \xc3\x28")},
+		{"binary", []byte{0, 1, 2, 0xff, '\r', '\n', 0}},
+		{"huge", huge},
+	}
+}
+
+// Serializer checks that the serializer function handles the edge cases
+// without panicking, returns the same result for the same input, doesn't
+// modify its input and can be called concurrently
+func Serializer(t *testing.T, f agenda.StringSerializerFunc) {
+	for _, in := range inputs() {
+		in := in
+		t.Run(in.name, func(t *testing.T) {
+			orig := clone(in.data)
+
+			s1, err1 := serialize(f, in.data)
+			if err1 != nil {
+				t.Logf("Serializer returned an error: %v", err1)
+			}
+			checkUnmodified(t, orig, in.data)
+
+			s2, err2 := serialize(f, in.data)
+			if s1 != s2 || fmt.Sprint(err1) != fmt.Sprint(err2) {
+				t.Errorf("Serializer is not deterministic")
+			}
+		})
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		in := []byte("line 1\nline 2\n")
+		want, wantErr := serialize(f, in)
+		concurrently(t, func() error {
+			got, err := serialize(f, in)
+			if got != want || fmt.Sprint(err) != fmt.Sprint(wantErr) {
+				return fmt.Errorf("got (%q, %v), want (%q, %v)", got, err, want, wantErr)
+			}
+			return nil
+		})
+	})
+}
+
+// Comparator checks that the comparator function reports no differences
+// for equal data and reports differences (or an error) for different data,
+// handles the edge cases without panicking, doesn't modify its input
+// and can be called concurrently
+func Comparator(t *testing.T, f agenda.ComparatorFunc) {
+	for _, in := range inputs() {
+		in := in
+		t.Run(in.name, func(t *testing.T) {
+			orig := clone(in.data)
+
+			diff, err := compare(f, in.data, clone(in.data))
+			if err != nil {
+				t.Logf("Comparator returned an error: %v", err)
+			} else if diff != "" {
+				t.Errorf("Expected no differences for equal data, got:\n%s", diff)
+			}
+			checkUnmodified(t, orig, in.data)
+
+			changed := append(clone(in.data), "\nextra"...)
+			diff, err = compare(f, in.data, changed)
+			if err == nil && diff == "" {
+				t.Errorf("Expected the differences to be reported")
+			}
+			checkUnmodified(t, orig, in.data)
+		})
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		a, b := []byte("line 1\nline 2\n"), []byte("line 1\nline 3\n")
+		want, wantErr := compare(f, a, b)
+		concurrently(t, func() error {
+			got, err := compare(f, a, b)
+			if got != want || fmt.Sprint(err) != fmt.Sprint(wantErr) {
+				return fmt.Errorf("got (%q, %v), want (%q, %v)", got, err, want, wantErr)
+			}
+			return nil
+		})
+	})
+}
+
+// readableFS is implemented by file systems which allow reading
+// the written files back (e.g. agenda.MemFS)
+type readableFS interface {
+	ReadFile(name string) ([]byte, error)
+}
+
+// WritableFS checks that the file system accepts the edge cases,
+// nested directories and concurrent writes. All files are written
+// under the `dir` directory. If the file system implements
+// `ReadFile(name string) ([]byte, error)`, the written contents
+// are read back and verified.
+func WritableFS(t *testing.T, fsys agenda.WritableFS, dir string) {
+	write := func(name string, data []byte) error {
+		orig := clone(data)
+		if err := fsys.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return fmt.Errorf("MkdirAll: %v", err)
+		}
+		if err := fsys.WriteFile(name, data, 0644); err != nil {
+			return fmt.Errorf("WriteFile: %v", err)
+		}
+		if !bytes.Equal(orig, data) {
+			return fmt.Errorf("WriteFile modified its input")
+		}
+		return verify(fsys, name, orig)
+	}
+
+	for i, in := range inputs() {
+		in := in
+		name := filepath.Join(dir, "inputs", fmt.Sprintf("%d.result", i))
+		t.Run(in.name, func(t *testing.T) {
+			if err := write(name, in.data); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		if err := write(filepath.Join(dir, "a", "b", "c", "1.result"), []byte("nested")); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		name := filepath.Join(dir, "overwrite.result")
+		if err := write(name, []byte("a longer version")); err != nil {
+			t.Error(err)
+		}
+		if err := write(name, []byte("short")); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		var mu sync.Mutex
+		n := 0
+		concurrently(t, func() error {
+			mu.Lock()
+			n++
+			name := filepath.Join(dir, "concurrent", fmt.Sprintf("%d", n%4), fmt.Sprintf("%d.result", n))
+			mu.Unlock()
+			return write(name, []byte(name))
+		})
+	})
+}
+
+// verify checks the written contents when the file system allows reading
+func verify(fsys agenda.WritableFS, name string, want []byte) error {
+	r, ok := fsys.(readableFS)
+	if !ok {
+		return nil
+	}
+	got, err := r.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("'%s' contains %d bytes, want %d", name, len(got), len(want))
+	}
+	return nil
+}
+
+// serialize calls the serializer, converting a panic into an error
+func serialize(f agenda.StringSerializerFunc, data []byte) (s string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return f(data)
+}
+
+// compare calls the comparator, converting a panic into an error
+func compare(f agenda.ComparatorFunc, reference, output []byte) (diff string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return f(reference, output)
+}
+
+// concurrently calls `f` from multiple goroutines
+// and reports the errors it returns
+func concurrently(t *testing.T, f func() error) {
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrency)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func checkUnmodified(t *testing.T, orig, data []byte) {
+	if !bytes.Equal(orig, data) {
+		t.Errorf("The input data was modified")
+	}
+}
+
+func clone(data []byte) []byte {
+	if data == nil {
+		return nil
+	}
+	return append([]byte{}, data...)
+}
@@ -0,0 +1,40 @@
+package extensiontest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/iafan/agenda"
+)
+
+func TestSerializer(t *testing.T) {
+	Serializer(t, func(data []byte) (string, error) {
+		return hex.Dump(data), nil
+	})
+}
+
+func TestComparator(t *testing.T) {
+	Comparator(t, func(reference, output []byte) (string, error) {
+		if bytes.Equal(reference, output) {
+			return "", nil
+		}
+		return "data differs", nil
+	})
+}
+
+func TestMemFS(t *testing.T) {
+	WritableFS(t, agenda.NewMemFS(), "testdata")
+}
+
+func TestDirFS(t *testing.T) {
+	root, err := ioutil.TempDir("", "extensiontest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	WritableFS(t, agenda.DirFS(root), "testdata")
+}
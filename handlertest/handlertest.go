@@ -0,0 +1,175 @@
+/*
+
+Package handlertest turns agenda into a lightweight golden-testing framework
+for HTTP handlers.
+
+Each input data file describes a request:
+
+	{
+		"method": "POST",
+		"path": "/users?notify=1",
+		"headers": {"Content-Type": "application/json"},
+		"body": {"name": "John"}
+	}
+
+The body can be either a JSON value (sent as is) or a string. The request
+is served by the handler, and the canonical serialization of the response
+(the status line, the headers sorted by name, and the body) becomes
+the snapshot artifact:
+
+	HTTP 201 Created
+	Content-Type: application/json
+
+	{"id":1,"name":"John"}
+
+Example:
+
+	agenda.Run(t, "./testdata/api", handlertest.Test(NewRouter()))
+
+*/
+package handlertest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+
+	"github.com/iafan/agenda"
+)
+
+// Request describes the request read from the input data file
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// config is an internal structure that holds the options
+type config struct {
+	ignore map[string]bool
+}
+
+// option is a type of the function that can modify
+// one or more of the options in the config structure
+type option func(c *config)
+
+// IgnoreHeaders excludes the response headers which change
+// from run to run (e.g. "Date" or "X-Request-Id") from the snapshot
+func IgnoreHeaders(names ...string) option {
+	return func(c *config) {
+		for _, name := range names {
+			c.ignore[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// Test returns the agenda test function which serves the request
+// described by each input data file with the handler, and returns
+// the canonical serialization of the response
+func Test(h http.Handler, options ...option) agenda.Test {
+	if h == nil {
+		panic("handler is nil")
+	}
+
+	c := &config{ignore: make(map[string]bool)}
+	for _, f := range options {
+		f(c)
+	}
+
+	return func(path string, data []byte) ([]byte, error) {
+		req, err := NewRequest(data)
+		if err != nil {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return Serialize(w.Result().StatusCode, w.Header(), w.Body.Bytes(), c.ignore), nil
+	}
+}
+
+// NewRequest creates the request described by the JSON data
+// (see Request)
+func NewRequest(data []byte) (*http.Request, error) {
+	var in Request
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+	if in.Path == "" {
+		return nil, errors.New("request path is not specified")
+	}
+	if in.Method == "" {
+		in.Method = http.MethodGet
+	}
+
+	var body []byte
+	if len(in.Body) > 0 && in.Body[0] == '"' {
+		var s string
+		if err := json.Unmarshal(in.Body, &s); err != nil {
+			return nil, err
+		}
+		body = []byte(s)
+	} else if len(in.Body) > 0 && string(in.Body) != "null" {
+		body = in.Body
+	}
+
+	// the path has to be a valid request target, as sent by the clients
+	if strings.ContainsAny(in.Path, " \t\r\n") {
+		return nil, fmt.Errorf("request path %q contains whitespace", in.Path)
+	}
+	req, err := http.NewRequest(in.Method, in.Path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if !req.URL.IsAbs() && !strings.HasPrefix(in.Path, "/") {
+		return nil, fmt.Errorf("request path %q must start with '/'", in.Path)
+	}
+
+	// make it look like an incoming server request (see httptest.NewRequest)
+	req.RequestURI = in.Path
+	req.RemoteAddr = "192.0.2.1:1234"
+	if req.Host == "" {
+		req.Host = "example.com"
+	}
+	if req.URL.Scheme == "https" {
+		req.TLS = &tls.ConnectionState{
+			Version:           tls.VersionTLS12,
+			HandshakeComplete: true,
+			ServerName:        req.Host,
+		}
+	}
+	for k, v := range in.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// Serialize renders the canonical serialization of the response:
+// the status line, the headers sorted by name (except the ignored ones),
+// an empty line and the body
+func Serialize(status int, header http.Header, body []byte, ignore map[string]bool) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "HTTP %d %s\n", status, http.StatusText(status))
+
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		if !ignore[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s: %s\n", k, strings.Join(header[k], ", "))
+	}
+
+	buf.WriteByte('\n')
+	buf.Write(body)
+	return buf.Bytes()
+}
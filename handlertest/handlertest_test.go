@@ -0,0 +1,60 @@
+package handlertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/iafan/agenda"
+)
+
+// usersHandler is a sample handler of the users API
+var usersHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Date", time.Now().Format(http.TimeFormat))
+	switch req.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "user %s", req.URL.Query().Get("id"))
+	case http.MethodPost:
+		in := struct {
+			Name string `json:"name"`
+		}{}
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/users?id=1")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1, "name": in.Name})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+})
+
+// TestHandler runs agenda tests against the sample handler of the users API
+func TestHandler(t *testing.T) {
+	agenda.Run(t, "testdata/api", Test(usersHandler, IgnoreHeaders("date")))
+}
+
+// TestNewRequest is a traditional (non agenda-based) test
+// that checks that invalid request paths are reported as errors
+func TestNewRequest(t *testing.T) {
+	for _, path := range []string{"users", "/a b", "/a%zz"} {
+		data, _ := json.Marshal(Request{Path: path})
+		if _, err := NewRequest(data); err == nil {
+			t.Errorf("Expected an error for the path %q", path)
+		}
+	}
+
+	req, err := NewRequest([]byte(`{"path": "/users?id=1", "headers": {"X-Test": "1"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodGet || req.RequestURI != "/users?id=1" ||
+		req.Host != "example.com" || req.Header.Get("X-Test") != "1" {
+		t.Errorf("Unexpected request: %+v", req)
+	}
+}
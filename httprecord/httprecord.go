@@ -0,0 +1,330 @@
+/*
+
+Package httprecord provides VCR-style recording and replaying of HTTP
+interactions for agenda tests, making API client code snapshot-testable
+offline.
+
+In initialization mode (`go test -args init`), the requests made by the test
+function are sent to the real server, and the interactions are recorded into
+the cassette file next to the input data file (e.g. "01.json.cassette").
+In regular mode, the responses are replayed from the cassette, and no requests
+leave the process. Use the Files() option to make the recorder follow
+the InitMode(), Output() and other file options of the agenda run.
+
+Example:
+
+	agenda.Run(t, "./testdata/mytest", httprecord.Test(
+		func(path string, data []byte, client *http.Client) ([]byte, error) {
+			api := NewAPIClient(client)
+			// ...
+		}))
+
+*/
+package httprecord
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/iafan/agenda"
+)
+
+// CassetteSuffix is appended to the input data file name
+// to get the name of the cassette file
+const CassetteSuffix = ".cassette"
+
+// redactedHeaders are not recorded in the cassette
+var redactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "Set-Cookie"}
+
+// Cassette is the contents of the cassette file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request describes the recorded request
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body
+}
+
+// Response describes the recorded response
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body
+}
+
+// Body is the recorded body; bodies which are not valid UTF-8
+// are stored base64-encoded
+type Body struct {
+	Body       string `json:"body,omitempty"`
+	BodyBase64 string `json:"bodyBase64,omitempty"`
+}
+
+func newBody(data []byte) Body {
+	if utf8.Valid(data) {
+		return Body{Body: string(data)}
+	}
+	return Body{BodyBase64: base64.StdEncoding.EncodeToString(data)}
+}
+
+// Bytes returns the decoded body
+func (b Body) Bytes() ([]byte, error) {
+	if b.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(b.BodyBase64)
+	}
+	return []byte(b.Body), nil
+}
+
+// config is an internal structure that holds the options
+type config struct {
+	record    *bool
+	transport http.RoundTripper
+	files     *agenda.FileWriter
+}
+
+// option is a type of the function that can modify
+// one or more of the options in the config structure
+type option func(c *config)
+
+// Transport sets the transport used to send the requests
+// in recording mode (http.DefaultTransport by default)
+func Transport(rt http.RoundTripper) option {
+	return func(c *config) {
+		c.transport = rt
+	}
+}
+
+// RecordMode overrides the mode, which by default follows
+// the initialization mode of agenda (`go test -args init`,
+// or the InitMode() option passed to Files())
+func RecordMode(enabled bool) option {
+	return func(c *config) {
+		c.record = &enabled
+	}
+}
+
+// Files makes the recorder follow the options of the agenda run:
+// cassettes are recorded in agenda initialization mode and written
+// with the writer, honouring agenda.Output(), agenda.ConfineWrites(),
+// agenda.FileMode() and agenda.DirMode().
+//
+// Example:
+//
+//	agenda.Run(t, "./testdata/mytest", httprecord.Test(testFunc,
+//		httprecord.Files(agenda.NewFileWriter("./testdata/mytest", agenda.ConfineWrites()))))
+func Files(w *agenda.FileWriter) option {
+	return func(c *config) {
+		c.files = w
+	}
+}
+
+// Recorder is an http.RoundTripper which records or replays
+// the HTTP interactions. It is safe for concurrent use.
+type Recorder struct {
+	path      string
+	record    bool
+	transport http.RoundTripper
+	files     *agenda.FileWriter
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder returns the recorder for the cassette file. In replay mode,
+// the cassette is read immediately.
+func NewRecorder(path string, options ...option) (*Recorder, error) {
+	c := &config{
+		transport: http.DefaultTransport,
+	}
+	for _, f := range options {
+		f(c)
+	}
+	if c.files == nil {
+		c.files = agenda.NewFileWriter(filepath.Dir(path))
+	}
+	record := c.files.InitMode()
+	if c.record != nil {
+		record = *c.record
+	}
+
+	r := &Recorder{path: path, record: record, transport: c.transport, files: c.files}
+	if r.record {
+		return r, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("cassette '%s' doesn't exist (try recording it with 'go test -args init')", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("can't parse cassette '%s': %v", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Client returns the HTTP client which uses the recorder as its transport
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip sends the request to the server and records the interaction
+// in recording mode, or returns the recorded response in replay mode.
+// Requests are matched by method, URL and body, in the recorded order.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	if r.record {
+		return r.recordTrip(req, body)
+	}
+	return r.replayTrip(req, body)
+}
+
+func (r *Recorder) recordTrip(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: redact(req.Header),
+			Body:   newBody(body),
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: redact(resp.Header),
+			Body:   newBody(respBody),
+		},
+	})
+	return resp, nil
+}
+
+func (r *Recorder) replayTrip(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || in.Request.Method != req.Method || in.Request.URL != req.URL.String() {
+			continue
+		}
+		if recorded, err := in.Request.Bytes(); err != nil || !bytes.Equal(recorded, body) {
+			continue
+		}
+
+		respBody, err := in.Response.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+			StatusCode:    in.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header,
+			Body:          ioutil.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction for %s %s in '%s'", req.Method, req.URL, r.path)
+}
+
+// Save writes the recorded interactions to the cassette file;
+// it does nothing in replay mode
+func (r *Recorder) Save() error {
+	if !r.record {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return r.files.WriteFile(r.path, append(data, '\n'))
+}
+
+// Test adapts the test function which makes HTTP requests with the provided
+// client into agenda.Test, recording or replaying the interactions
+// of each case (see NewRecorder())
+func Test(test func(path string, data []byte, client *http.Client) ([]byte, error), options ...option) agenda.Test {
+	if test == nil {
+		panic("test function is nil")
+	}
+
+	return func(path string, data []byte) ([]byte, error) {
+		r, err := NewRecorder(path+CassetteSuffix, options...)
+		if err != nil {
+			return nil, err
+		}
+
+		output, testErr := test(path, data, r.Client())
+		if err := r.Save(); err != nil {
+			return nil, errors.New("can't save the cassette: " + err.Error())
+		}
+		return output, testErr
+	}
+}
+
+// redact returns the copy of the headers without the sensitive ones
+// and the ones which change on every request
+func redact(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, v := range h {
+		out[k] = v
+	}
+	for _, k := range redactedHeaders {
+		out.Del(k)
+	}
+	out.Del("Date")
+
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
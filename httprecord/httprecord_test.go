@@ -0,0 +1,139 @@
+package httprecord
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iafan/agenda"
+)
+
+// handlerTransport serves the requests with the handler, without network
+type handlerTransport struct {
+	handler http.Handler
+	calls   int
+}
+
+func (t *handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	w := httptest.NewRecorder()
+	t.handler.ServeHTTP(w, req)
+	return w.Result(), nil
+}
+
+// fakeAPI greets the user whose name is passed in the request
+var fakeAPI = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Set-Cookie", "session=secret")
+	fmt.Fprintf(w, "Hello, %s (%s)", body, req.URL.Query().Get("lang"))
+})
+
+// greet is a sample test function which calls the API
+func greet(path string, data []byte, client *http.Client) ([]byte, error) {
+	in := struct {
+		Name string `json:"name"`
+	}{}
+
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Post("http://api.example.com/greet?lang=en", "text/plain", strings.NewReader(in.Name))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%d %s", resp.StatusCode, body)), nil
+}
+
+// TestReplay runs agenda tests whose HTTP interactions are recorded
+// in cassettes; running `go test -args init` re-records them
+// with the fake API
+func TestReplay(t *testing.T) {
+	agenda.Run(t, "testdata/greet", Test(greet, Transport(&handlerTransport{handler: fakeAPI})))
+}
+
+// TestRecordReplay is a traditional (non agenda-based) test
+// that records a cassette and replays it without calling the API
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httprecord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "1.json")
+
+	api := &handlerTransport{handler: fakeAPI}
+	recorded, err := Test(greet, RecordMode(true), Transport(api))(path, []byte(`{"name":"world"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cassette, err := ioutil.ReadFile(path + CassetteSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(cassette), "secret") {
+		t.Errorf("Expected the cookie to be redacted, got:\n%s", cassette)
+	}
+
+	replayed, err := Test(greet, RecordMode(false), Transport(api))(path, []byte(`{"name":"world"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(replayed) != string(recorded) || api.calls != 1 {
+		t.Errorf("Expected %q to be replayed without calling the API, got %q (%d calls)",
+			recorded, replayed, api.calls)
+	}
+
+	_, err = Test(greet, RecordMode(false))(path, []byte(`{"name":"nobody"}`))
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction for POST") {
+		t.Errorf("Expected an unmatched request to fail, got %v", err)
+	}
+}
+
+// TestRecordFiles is a traditional (non agenda-based) test that checks
+// that cassettes are recorded in agenda initialization mode and written
+// according to the agenda options
+func TestRecordFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httprecord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "1.json")
+
+	fsys := agenda.NewMemFS()
+	files := agenda.NewFileWriter(dir, agenda.InitMode(true), agenda.Output(fsys))
+	api := &handlerTransport{handler: fakeAPI}
+	if _, err := Test(greet, Files(files), Transport(api))(path, []byte(`{"name":"world"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if api.calls != 1 {
+		t.Errorf("Expected the API to be called once, got %d calls", api.calls)
+	}
+	if _, err := fsys.ReadFile(path + CassetteSuffix); err != nil {
+		t.Errorf("Expected the cassette to be written to the output file system: %v", err)
+	}
+	if _, err := os.Stat(path + CassetteSuffix); !os.IsNotExist(err) {
+		t.Errorf("Expected the cassette not to be written to disk, got %v", err)
+	}
+
+	files = agenda.NewFileWriter(filepath.Join(dir, "other"), agenda.InitMode(true), agenda.ConfineWrites())
+	_, err = Test(greet, Files(files), Transport(api))(path, []byte(`{"name":"world"}`))
+	if err == nil || !strings.Contains(err.Error(), "outside of the test data directories") {
+		t.Errorf("Expected the confined write to fail, got %v", err)
+	}
+}